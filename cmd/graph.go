@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"helm-depcheck/pkg/graph"
+)
+
+// newGraphCommand creates the command rendering the dependency graph of a chart
+func newGraphCommand() *cobra.Command {
+	var format string
+
+	cmd := &cobra.Command{
+		Use:   "graph CHART",
+		Short: "Render the dependency graph of a chart",
+		Long: `Render the chart's declared dependencies and the deployed releases
+matching them as a Graphviz DOT, Mermaid or JSON graph.
+
+Releases are shown as namespace/release@version. Edges are colored by
+the status of the dependency: green when satisfied, orange when a
+release was found but does not match and red when it is missing or
+conflicts. The graph is printed whether or not the check succeeds.`,
+		Example: `  # Render the graph with Graphviz
+  helm dependency-check graph ./my-chart | dot -Tsvg > dependencies.svg
+
+  # Include the requirements of the deployed dependencies
+  helm dependency-check graph --transitive --format mermaid ./my-chart`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			switch format {
+			case graph.FormatDOT, graph.FormatMermaid, graph.FormatJSON:
+				// Valid formats
+			default:
+				return fmt.Errorf("invalid graph format '%s': must be one of dot, mermaid, json", format)
+			}
+
+			result, err := performCheck(args[0])
+			if err != nil {
+				return err
+			}
+
+			output, err := graph.NewDiagram(result).Render(format)
+			if err != nil {
+				return fmt.Errorf("failed to render graph: %v", err)
+			}
+
+			_, err = os.Stdout.Write(output)
+			return err
+		},
+	}
+
+	cmd.Flags().StringVarP(&format, "format", "f", graph.FormatDOT,
+		"Graph format (dot, mermaid, json)")
+
+	return cmd
+}
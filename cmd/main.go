@@ -5,12 +5,14 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v3"
 
 	"helm-depcheck/pkg/checker"
 	"helm-depcheck/pkg/helm"
+	"helm-depcheck/pkg/helm/fake"
 	"helm-depcheck/pkg/parser"
 	"helm-depcheck/pkg/types"
 )
@@ -21,41 +23,87 @@ const (
 )
 
 var (
-	config  types.Config
-	version = "1.0.0"
+	config      types.Config
+	assumptions []string
+	version     = "1.0.0"
 )
 
 func main() {
 	rootCmd := &cobra.Command{
-		Use:   "helm-depcheck CHART_PATH",
+		Use:   "helm-depcheck CHART",
 		Short: "Check Helm chart dependencies compatibility",
 		Long: `A Helm plugin that validates chart dependencies by checking
 deployed releases compatibility with semver constraints.
 
 This tool reads dependencies.yaml from your chart and validates that
-deployed releases meet the specified version constraints.`,
+deployed releases meet the specified version constraints.
+
+CHART may be a chart directory, a packaged chart archive (.tgz) or an
+oci:// reference.`,
 		Version: version,
 		Args:    cobra.ExactArgs(1),
 		RunE:    runCheck,
 	}
 
-	// Add flags
-	rootCmd.Flags().StringVarP(&config.NamespacePattern, "namespace-pattern", "p", "",
+	// Add check flags, shared with the graph and reverse commands
+	rootCmd.PersistentFlags().StringVarP(&config.NamespacePattern, "namespace-pattern", "p", "",
 		"Regular expression for filtering namespaces (default: all non-system namespaces)")
+	rootCmd.PersistentFlags().StringVarP(&config.TargetNamespace, "namespace", "n", os.Getenv("HELM_NAMESPACE"),
+		"Namespace the chart is installed into, used by dependencies with scope same-namespace")
+	rootCmd.PersistentFlags().StringVar(&config.KubeConfig, "kubeconfig", "",
+		"Path to kubeconfig file")
+	rootCmd.PersistentFlags().StringVar(&config.ReleasesFixture, "releases-fixture", "",
+		"Path to a YAML fixture with namespaces and releases to check against instead of a cluster")
+	rootCmd.PersistentFlags().IntVar(&config.Concurrency, "concurrency", helm.DefaultConcurrency,
+		"Maximum number of namespaces to list releases from in parallel")
+	rootCmd.PersistentFlags().DurationVar(&config.Timeout, "timeout", 5*time.Minute,
+		"Deadline for querying the cluster (0 disables the deadline)")
+	rootCmd.PersistentFlags().BoolVar(&config.StrictAccess, "strict-access", false,
+		"Fail the check if releases in any matched namespace cannot be read")
+	rootCmd.PersistentFlags().StringVar(&config.StorageDriver, "storage-driver", "",
+		"Helm storage driver holding release data: secret, configmap, memory or sql (default: $HELM_DRIVER or secret)")
+	rootCmd.PersistentFlags().StringVar(&config.SQLConnectionString, "sql-connection-string", "",
+		"Connection string for the sql storage driver (default: $HELM_DRIVER_SQL_CONNECTION_STRING)")
+	rootCmd.PersistentFlags().BoolVar(&config.Recursive, "recursive", false,
+		"Also check the requirements of subcharts in charts/ and merge them with the chart's own")
+	rootCmd.PersistentFlags().BoolVar(&config.Transitive, "transitive", false,
+		"Also check the requirements stored with the charts of the deployed releases the chart depends on")
+	rootCmd.PersistentFlags().StringVar(&config.OCILayout, "oci-layout", "",
+		"Read oci:// charts from this local OCI image layout directory instead of the registry")
+	rootCmd.PersistentFlags().StringArrayVar(&assumptions, "assume", nil,
+		"Assume a release runs the given chart version, as namespace/release=chart@version (repeatable)")
+
+	// Add output flags
 	rootCmd.Flags().BoolVarP(&config.Verbose, "verbose", "v", false,
 		"Enable verbose output")
 	rootCmd.Flags().StringVarP(&config.OutputFormat, "output", "o", "text",
 		"Output format (text, json, yaml)")
-	rootCmd.Flags().StringVar(&config.KubeConfig, "kubeconfig", "",
-		"Path to kubeconfig file")
 
 	// Add examples
 	rootCmd.Example = `  # Check dependencies for chart in current directory
   helm dependency-check ./my-chart
 
+  # Check a packaged chart archive
+  helm dependency-check ./my-chart-1.2.0.tgz
+
+  # Check a chart stored in an OCI registry
+  helm dependency-check oci://registry.example.com/charts/my-chart:1.2.0
+
+  # Check a chart from a local OCI image layout
+  helm dependency-check --oci-layout ./oci-layout oci://registry.example.com/charts/my-chart:1.2.0
+
+  # Check an umbrella chart together with all of its subcharts
+  helm dependency-check --recursive ./platform
+
+  # Also check what the deployed dependencies themselves require
+  helm dependency-check --transitive ./my-chart
+
   # Check with specific namespace pattern
   helm dependency-check --namespace-pattern "develop.*" ./charts/api
 
+  # Require same-namespace dependencies in the namespace being installed into
+  helm dependency-check --namespace team-a ./charts/api
+
   # Output in JSON format
   helm dependency-check --output json ./frontend
 
@@ -63,7 +111,26 @@ deployed releases meet the specified version constraints.`,
   helm dependency-check --namespace-pattern ".*" ./system-chart
 
   # Use specific kubeconfig
-  helm dependency-check --kubeconfig /path/to/config ./my-chart`
+  helm dependency-check --kubeconfig /path/to/config ./my-chart
+
+  # List up to 50 namespaces in parallel and give up after one minute
+  helm dependency-check --concurrency 50 --timeout 1m ./my-chart
+
+  # Fail if any matched namespace cannot be read
+  helm dependency-check --strict-access ./my-chart
+
+  # Discover releases stored in ConfigMaps
+  helm dependency-check --storage-driver configmap ./my-chart
+
+  # Check whether the chart still passes if redis were upgraded to 8.0.0
+  helm dependency-check --assume shared/redis=redis@8.0.0 ./my-chart
+
+  # Check against releases from a fixture file instead of a cluster
+  helm dependency-check --releases-fixture ./testdata/releases.yaml ./my-chart`
+
+	rootCmd.AddCommand(newSchemaCommand())
+	rootCmd.AddCommand(newGraphCommand())
+	rootCmd.AddCommand(newReverseCommand())
 
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
@@ -72,58 +139,118 @@ deployed releases meet the specified version constraints.`,
 }
 
 func runCheck(cmd *cobra.Command, args []string) error {
-	config.ChartPath = args[0]
+	result, err := performCheck(args[0])
+	if err != nil {
+		return err
+	}
+
+	// Output results
+	if err := outputResults(result); err != nil {
+		return fmt.Errorf("failed to output results: %v", err)
+	}
+
+	// Exit with appropriate code
+	if !result.Success {
+		os.Exit(exitFailure)
+	}
+
+	return nil
+}
+
+// performCheck checks the dependencies of the given chart against the deployed releases
+func performCheck(chartPath string) (*types.CheckResult, error) {
+	config.ChartPath = chartPath
 
 	// Validate configuration
+	if err := parseAssumptions(); err != nil {
+		return nil, err
+	}
 	if err := validateConfig(); err != nil {
-		return fmt.Errorf("configuration validation failed: %v", err)
+		return nil, fmt.Errorf("configuration validation failed: %v", err)
 	}
 
-	// Create Helm client
-	helmClient, err := helm.NewClient(config.KubeConfig)
+	// Create release source
+	releaseSource, err := newReleaseSource()
 	if err != nil {
-		return fmt.Errorf("failed to create Helm client: %v", err)
-	}
-
-	// Validate Helm/Kubernetes connection
-	if err := helmClient.HealthCheck(); err != nil {
-		return fmt.Errorf("health check failed: %v", err)
+		return nil, err
 	}
 
 	// Create parser
 	parserInstance := parser.NewParser()
+	parserInstance.SetPullFunc(helm.PullChart)
+	parserInstance.SetOCILayout(config.OCILayout)
 
 	// Create checker
-	checkerInstance := checker.NewChecker(helmClient, parserInstance)
+	checkerInstance := checker.NewChecker(releaseSource, parserInstance)
 
 	// Validate checker config
 	if err := checkerInstance.ValidateConfig(config); err != nil {
-		return fmt.Errorf("checker configuration validation failed: %v", err)
+		return nil, fmt.Errorf("checker configuration validation failed: %v", err)
 	}
 
 	// Perform the check
 	result, err := checkerInstance.Check(config)
 	if err != nil {
-		return fmt.Errorf("dependency check failed: %v", err)
+		return nil, fmt.Errorf("dependency check failed: %v", err)
 	}
 
-	// Output results
-	if err := outputResults(result); err != nil {
-		return fmt.Errorf("failed to output results: %v", err)
+	return result, nil
+}
+
+// newReleaseSource creates the release source, using the releases fixture if one is configured
+func newReleaseSource() (checker.ReleaseSource, error) {
+	if config.ReleasesFixture != "" {
+		fixtureSource, err := fake.LoadFixture(config.ReleasesFixture)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load releases fixture: %v", err)
+		}
+		return fixtureSource, nil
 	}
 
-	// Exit with appropriate code
-	if !result.Success {
-		os.Exit(exitFailure)
+	// Create Helm client
+	helmClient, err := helm.NewClient(helm.Options{
+		KubeConfig:          config.KubeConfig,
+		Concurrency:         config.Concurrency,
+		StorageDriver:       config.StorageDriver,
+		SQLConnectionString: config.SQLConnectionString,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Helm client: %v", err)
+	}
+
+	// Validate Helm/Kubernetes connection
+	if err := helmClient.HealthCheck(); err != nil {
+		return nil, fmt.Errorf("health check failed: %v", err)
 	}
 
+	return helmClient, nil
+}
+
+// parseAssumptions parses the --assume overrides into the configuration
+func parseAssumptions() error {
+	config.Assumptions = nil
+	for _, value := range assumptions {
+		assumption, err := types.ParseAssumption(value)
+		if err != nil {
+			return fmt.Errorf("configuration validation failed: %v", err)
+		}
+		config.Assumptions = append(config.Assumptions, assumption)
+	}
 	return nil
 }
 
 func validateConfig() error {
-	// Check if chart path exists
-	if _, err := os.Stat(config.ChartPath); os.IsNotExist(err) {
-		return fmt.Errorf("chart path does not exist: %s", config.ChartPath)
+	// Check if chart path exists; OCI references are resolved when the chart is loaded
+	if !parser.IsOCIReference(config.ChartPath) {
+		if _, err := os.Stat(config.ChartPath); os.IsNotExist(err) {
+			return fmt.Errorf("chart path does not exist: %s", config.ChartPath)
+		}
+	}
+
+	if config.OCILayout != "" {
+		if _, err := os.Stat(config.OCILayout); err != nil {
+			return fmt.Errorf("OCI layout does not exist: %s", config.OCILayout)
+		}
 	}
 
 	return nil
@@ -140,13 +267,13 @@ func outputResults(result *types.CheckResult) error {
 	}
 }
 
-func outputJSON(result *types.CheckResult) error {
+func outputJSON(result any) error {
 	encoder := json.NewEncoder(os.Stdout)
 	encoder.SetIndent("", "  ")
 	return encoder.Encode(result)
 }
 
-func outputYAML(result *types.CheckResult) error {
+func outputYAML(result any) error {
 	encoder := yaml.NewEncoder(os.Stdout)
 	defer encoder.Close()
 	return encoder.Encode(result)
@@ -157,6 +284,8 @@ func outputText(result *types.CheckResult) error {
 	fmt.Println("Dependency Check Results")
 	fmt.Print("========================\n\n")
 
+	printAssumptions(result.Assumptions)
+
 	// Print matched namespaces
 	if config.Verbose {
 		if len(result.MatchedNamespaces) > 0 {
@@ -166,10 +295,14 @@ func outputText(result *types.CheckResult) error {
 		} else {
 			fmt.Println("No namespaces matched the pattern")
 		}
+		if result.TargetNamespace != "" {
+			fmt.Printf("Target Namespace: %s\n", result.TargetNamespace)
+		}
 	}
 
-	if result.Summary.Total == 0 {
-		fmt.Println("No dependencies found in dependencies.yaml")
+	if result.Summary.Total == 0 && len(result.Conflicts) == 0 && len(result.Errors) == 0 {
+		fmt.Print("No dependencies found in dependencies.yaml or the Chart.yaml annotation\n\n")
+		printWarnings(result.Warnings)
 		return nil
 	}
 
@@ -185,50 +318,70 @@ func outputText(result *types.CheckResult) error {
 	if result.Summary.Multiple > 0 {
 		fmt.Printf("✗ Multiple Found: %d\n", result.Summary.Multiple)
 	}
+	if result.Summary.Conflicts > 0 {
+		fmt.Printf("✗ Conflicts: %d\n", result.Summary.Conflicts)
+	}
+	if result.Summary.Contradictory > 0 {
+		fmt.Printf("✗ Contradictory Constraints: %d\n", result.Summary.Contradictory)
+	}
+	if result.Summary.NoAlternative > 0 {
+		fmt.Printf("✗ No Alternative Satisfied: %d\n", result.Summary.NoAlternative)
+	}
 	if result.Summary.Errors > 0 {
 		fmt.Printf("✗ Errors: %d\n", result.Summary.Errors)
 	}
+	if result.Summary.Transitive > 0 {
+		fmt.Printf("Transitive Requirements: %d\n", result.Summary.Transitive)
+	}
+	if result.Summary.BrokenLinks > 0 {
+		fmt.Printf("✗ Broken Transitive Links: %d\n", result.Summary.BrokenLinks)
+	}
+	if result.Summary.Cycles > 0 {
+		fmt.Printf("⟳ Dependency Cycles: %d\n", result.Summary.Cycles)
+	}
+	if result.Summary.Warnings > 0 {
+		fmt.Printf("⚠ Warnings: %d\n", result.Summary.Warnings)
+	}
 
 	fmt.Println()
 
 	// Print detailed results
-	if config.Verbose || !result.Success {
+	hasResults := len(result.Dependencies) > 0 || len(result.Conflicts) > 0 || len(result.Transitive) > 0
+	if hasResults && (config.Verbose || !result.Success) {
 		fmt.Println("Detailed Results:")
 		fmt.Println("-----------------")
 
 		for _, dep := range result.Dependencies {
-			status := getStatusSymbol(dep.Status)
-			fmt.Printf("%s %s (required: %s)", status, dep.Name, dep.RequiredVersion)
-
-			if config.Verbose || dep.Status != types.StatusSatisfied {
-				if len(dep.FoundReleases) > 0 {
-					for _, release := range dep.FoundReleases {
-						fmt.Println()
-						fmt.Printf("    Found: %s/%s (version: %s)",
-							release.Namespace, release.Name, release.Chart.Version)
-					}
-				}
-				if dep.Error != "" {
-					fmt.Printf("    Error: %s\n", dep.Error)
-				}
+			printDependencyResult(dep, "")
+		}
+		fmt.Println()
+
+		if len(result.Conflicts) > 0 {
+			fmt.Println("Conflict Rules:")
+			fmt.Println("---------------")
+			for _, conflict := range result.Conflicts {
+				printDependencyResult(conflict, "")
 			}
 			fmt.Println()
 		}
-		fmt.Println()
-	}
 
-	// Print errors
-	if len(result.Errors) > 0 {
-		fmt.Println("Errors:")
-		fmt.Println("-------")
-		for _, err := range result.Errors {
-			fmt.Println(err.Error())
+		if len(result.Transitive) > 0 {
+			fmt.Println("Transitive Requirements:")
+			fmt.Println("------------------------")
+			for _, dep := range result.Transitive {
+				printDependencyResult(dep, "")
+			}
+			fmt.Println()
 		}
-		fmt.Println()
 	}
 
+	printErrors(result.Errors)
+	printWarnings(result.Warnings)
+
 	// Print final status
-	if result.Success {
+	if result.Success && result.Summary.Warnings > 0 {
+		fmt.Printf("✓ All required dependencies satisfied (%d warnings)\n", result.Summary.Warnings)
+	} else if result.Success {
 		fmt.Println("✓ All dependencies satisfied!")
 	} else {
 		fmt.Println("✗ Dependency check failed!")
@@ -238,16 +391,167 @@ func outputText(result *types.CheckResult) error {
 	return nil
 }
 
+// printErrors prints the errors of a check
+func printErrors(errors []types.ValidationError) {
+	if len(errors) == 0 {
+		return
+	}
+
+	fmt.Println("Errors:")
+	fmt.Println("-------")
+	for _, err := range errors {
+		fmt.Println(err.Error())
+	}
+	fmt.Println()
+}
+
+// printWarnings prints the warnings of a check
+func printWarnings(warnings []types.ValidationError) {
+	if len(warnings) == 0 {
+		return
+	}
+
+	fmt.Println("Warnings:")
+	fmt.Println("---------")
+	for _, warning := range warnings {
+		fmt.Printf("⚠ %s\n", warning.Error())
+	}
+	fmt.Println()
+}
+
+// printAssumptions prints the assumed releases a what-if check was run with
+func printAssumptions(assumptions []types.Assumption) {
+	if len(assumptions) == 0 {
+		return
+	}
+
+	fmt.Println("Assuming (what-if, cluster unchanged):")
+	for _, assumption := range assumptions {
+		fmt.Printf("  %s/%s runs %s@%s\n", assumption.Namespace, assumption.Release, assumption.Chart, assumption.Version)
+	}
+	fmt.Println()
+}
+
+// printDependencyResult prints a single dependency result in text format. Alternatives of
+// anyOf groups are printed below the group with increased indentation.
+func printDependencyResult(dep types.DependencyResult, indent string) {
+	status := getDependencySymbol(dep)
+	if len(dep.Alternatives) > 0 {
+		fmt.Printf("%s%s %s (any of %d alternatives", indent, status, dep.Name, len(dep.Alternatives))
+		if dep.Chosen != "" {
+			fmt.Printf(", chosen: %s", dep.Chosen)
+		}
+	} else {
+		requirement := "required"
+		if dep.Status == types.StatusConflict || dep.Status == types.StatusNoConflict {
+			requirement = "forbidden"
+		}
+		fmt.Printf("%s%s %s (%s: %s", indent, status, dep.Name, requirement, dep.RequiredVersion)
+		if dep.RequiredAppVersion != "" {
+			fmt.Printf(", appVersion: %s", dep.RequiredAppVersion)
+		}
+	}
+	if dep.Namespace != "" {
+		fmt.Printf(", namespace: %s", dep.Namespace)
+	}
+	if dep.NamespacePattern != "" {
+		fmt.Printf(", namespace pattern: %s", dep.NamespacePattern)
+	}
+	if dep.ReleaseName != "" {
+		fmt.Printf(", release: %s", dep.ReleaseName)
+	}
+	if dep.ReleaseNamePattern != "" {
+		fmt.Printf(", release pattern: %s", dep.ReleaseNamePattern)
+	}
+	if dep.Optional {
+		fmt.Print(", optional")
+	} else if dep.Severity == types.SeverityWarning {
+		fmt.Print(", severity: warning")
+	}
+	fmt.Println(")")
+	if config.Verbose && dep.Source != "" && indent == "" && len(dep.DeclaredBy) == 0 {
+		fmt.Printf("    Declared in: %s\n", dep.Source)
+	}
+	for _, declaration := range dep.DeclaredBy {
+		fmt.Printf("%s    Declared by: %s", indent, declaration.Chart)
+		if declaration.Version != "" {
+			fmt.Printf(" (%s", declaration.Version)
+			if declaration.AppVersion != "" {
+				fmt.Printf(", appVersion: %s", declaration.AppVersion)
+			}
+			fmt.Print(")")
+		}
+		if config.Verbose && declaration.Source != "" {
+			fmt.Printf(" in %s", declaration.Source)
+		}
+		fmt.Println()
+	}
+	if dep.RequiredBy != "" {
+		fmt.Printf("%s    Required by: %s (level %d)\n", indent, dep.RequiredBy, dep.Depth)
+	}
+	if dep.Reason != "" {
+		fmt.Printf("%s    Reason: %s\n", indent, dep.Reason)
+	}
+
+	if len(dep.Alternatives) > 0 {
+		if config.Verbose || dep.Status != types.StatusSatisfied {
+			for _, alternative := range dep.Alternatives {
+				printDependencyResult(alternative, indent+"    ")
+			}
+		}
+		return
+	}
+
+	if config.Verbose || (dep.Status != types.StatusSatisfied && dep.Status != types.StatusNoConflict) {
+		for _, release := range dep.FoundReleases {
+			fmt.Printf("%s    Found: %s/%s (version: %s",
+				indent, release.Namespace, release.Name, release.Chart.Version)
+			if release.Chart.AppVersion != "" {
+				fmt.Printf(", appVersion: %s", release.Chart.AppVersion)
+			}
+			if release.Assumed {
+				fmt.Print(", assumed")
+			}
+			fmt.Println(")")
+		}
+		if dep.Error != "" {
+			fmt.Printf("%s    Error: %s\n", indent, dep.Error)
+		}
+	}
+}
+
+// getDependencySymbol returns the symbol for a dependency result, marking unsatisfied
+// optional and warning-severity dependencies distinctly from failures
+func getDependencySymbol(dep types.DependencyResult) string {
+	failed := dep.Status != types.StatusSatisfied && dep.Status != types.StatusNoConflict
+	if failed && (dep.Optional || dep.Severity == types.SeverityWarning) {
+		return "⚠"
+	}
+	return getStatusSymbol(dep.Status)
+}
+
 func getStatusSymbol(status string) string {
 	switch status {
-	case types.StatusSatisfied:
+	case types.StatusSatisfied, types.StatusNoConflict:
 		return "✓"
+	case types.StatusConflict:
+		return "✗"
 	case types.StatusNotFound:
 		return "✗"
 	case types.StatusVersionMismatch:
 		return "✗"
+	case types.StatusAppVersionMismatch:
+		return "✗"
 	case types.StatusMultipleFound:
 		return "✗"
+	case types.StatusInvalidAppVersion:
+		return "✗"
+	case types.StatusNoAlternative:
+		return "✗"
+	case types.StatusContradictory:
+		return "✗"
+	case types.StatusNoTargetNamespace:
+		return "✗"
 	case types.StatusError:
 		return "✗"
 	default:
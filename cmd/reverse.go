@@ -0,0 +1,185 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"helm-depcheck/pkg/checker"
+	"helm-depcheck/pkg/parser"
+	"helm-depcheck/pkg/types"
+)
+
+// newReverseCommand creates the command checking which deployed releases a proposed upgrade
+// or uninstall of a chart would break
+func newReverseCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "reverse",
+		Short: "Check which deployed releases an upgrade or uninstall would break",
+		Long: `Check which deployed releases depend on a chart and whether a proposed
+upgrade or uninstall of its releases would break them.
+
+The dependencies stored with the chart of every deployed release are
+evaluated twice: against the releases as deployed and against the
+releases as they would be after the change. Dependencies that are
+satisfied now but not afterwards break.`,
+		Example: `  # Check whether upgrading postgresql to 16.0.0 breaks any release
+  helm dependency-check reverse --chart postgresql --to-version 16.0.0
+
+  # Only upgrade a single release of the chart
+  helm dependency-check reverse --chart postgresql --release shared/db --to-version 16.0.0
+
+  # Check whether uninstalling redis breaks any release
+  helm dependency-check reverse --chart redis --uninstall`,
+		Args: cobra.NoArgs,
+		RunE: runReverse,
+	}
+
+	cmd.Flags().StringVar(&config.ReverseChart, "chart", "",
+		"Name of the chart whose releases are upgraded or uninstalled")
+	cmd.Flags().StringVar(&config.ReverseRelease, "release", "",
+		"Only change this release of the chart, as namespace/name (default: all releases of the chart)")
+	cmd.Flags().StringVar(&config.ToVersion, "to-version", "",
+		"Chart version the releases are upgraded to")
+	cmd.Flags().StringVar(&config.ToAppVersion, "to-app-version", "",
+		"App version the releases are upgraded to (default: unchanged)")
+	cmd.Flags().BoolVar(&config.Uninstall, "uninstall", false,
+		"Check uninstalling the releases instead of upgrading them")
+	cmd.Flags().BoolVarP(&config.Verbose, "verbose", "v", false,
+		"Enable verbose output")
+	cmd.Flags().StringVarP(&config.OutputFormat, "output", "o", "text",
+		"Output format (text, json, yaml)")
+	_ = cmd.MarkFlagRequired("chart")
+
+	return cmd
+}
+
+func runReverse(cmd *cobra.Command, args []string) error {
+	if err := parseAssumptions(); err != nil {
+		return err
+	}
+
+	// Create release source
+	releaseSource, err := newReleaseSource()
+	if err != nil {
+		return err
+	}
+
+	// Create checker
+	checkerInstance := checker.NewChecker(releaseSource, parser.NewParser())
+
+	// Validate checker config
+	if err := checkerInstance.ValidateReverseConfig(config); err != nil {
+		return fmt.Errorf("checker configuration validation failed: %v", err)
+	}
+
+	// Perform the reverse check
+	result, err := checkerInstance.CheckReverse(config)
+	if err != nil {
+		return fmt.Errorf("reverse dependency check failed: %v", err)
+	}
+
+	// Output results
+	if err := outputReverseResults(result); err != nil {
+		return fmt.Errorf("failed to output results: %v", err)
+	}
+
+	// Exit with appropriate code
+	if !result.Success {
+		os.Exit(exitFailure)
+	}
+
+	return nil
+}
+
+func outputReverseResults(result *types.ReverseResult) error {
+	switch types.OutputFormat(config.OutputFormat) {
+	case types.OutputFormatJSON:
+		return outputJSON(result)
+	case types.OutputFormatYAML:
+		return outputYAML(result)
+	default:
+		return outputReverseText(result)
+	}
+}
+
+func outputReverseText(result *types.ReverseResult) error {
+	// Print summary
+	fmt.Println("Reverse Dependency Check Results")
+	fmt.Print("================================\n\n")
+
+	printAssumptions(result.Assumptions)
+
+	change := "upgrade to " + result.ToVersion
+	if result.ToAppVersion != "" {
+		change += fmt.Sprintf(" (appVersion: %s)", result.ToAppVersion)
+	}
+	if result.Uninstall {
+		change = "uninstall"
+	}
+	fmt.Printf("Proposed Change: %s %s\n", change, result.Chart)
+	for _, release := range result.Changed {
+		fmt.Printf("  %s/%s (version: %s)\n", release.Namespace, release.Name, release.Chart.Version)
+	}
+	fmt.Println()
+
+	fmt.Printf("Releases Checked: %d\n", result.Summary.Releases)
+	fmt.Printf("Dependents: %d\n", result.Summary.Dependents)
+	fmt.Printf("✓ Unaffected: %d\n", result.Summary.Unaffected)
+	if result.Summary.Broken > 0 {
+		fmt.Printf("✗ Broken: %d\n", result.Summary.Broken)
+	}
+	if result.Summary.Unmet > 0 {
+		fmt.Printf("- Already Unmet: %d\n", result.Summary.Unmet)
+	}
+	if result.Summary.Warnings > 0 {
+		fmt.Printf("⚠ Warnings: %d\n", result.Summary.Warnings)
+	}
+	fmt.Println()
+
+	// Print detailed results, only breaking dependents unless verbose
+	var details []types.ReverseDependency
+	for _, dependent := range result.Dependents {
+		if config.Verbose || dependent.Breaks {
+			details = append(details, dependent)
+		}
+	}
+	if len(details) > 0 {
+		fmt.Println("Dependents:")
+		fmt.Println("-----------")
+		for _, dependent := range details {
+			fmt.Printf("%s %s\n", getReverseSymbol(dependent), dependent.RequiredBy)
+			fmt.Printf("    Now: %s\n", dependent.Current.Status)
+			printDependencyResult(dependent.Proposed, "    ")
+		}
+		fmt.Println()
+	}
+
+	printErrors(result.Errors)
+	printWarnings(result.Warnings)
+
+	// Print final status
+	if result.Success && result.Summary.Warnings > 0 {
+		fmt.Printf("✓ No required dependency breaks (%d warnings)\n", result.Summary.Warnings)
+	} else if result.Success {
+		fmt.Println("✓ No dependency breaks!")
+	} else {
+		fmt.Println("✗ The proposed change breaks deployed releases!")
+	}
+	fmt.Println()
+
+	return nil
+}
+
+// getReverseSymbol returns the symbol for a dependent of a reverse check
+func getReverseSymbol(dependent types.ReverseDependency) string {
+	switch {
+	case dependent.Breaks:
+		return getDependencySymbol(dependent.Proposed)
+	case dependent.Current.Status != types.StatusSatisfied:
+		return "-"
+	default:
+		return "✓"
+	}
+}
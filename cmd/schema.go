@@ -0,0 +1,27 @@
+package main
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"helm-depcheck/pkg/parser"
+)
+
+// newSchemaCommand creates the command printing the JSON Schema of dependencies.yaml
+func newSchemaCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "schema",
+		Short: "Print the JSON Schema of dependencies.yaml",
+		Long: `Print the JSON Schema describing dependencies.yaml and the
+helm-depcheck/dependencies Chart.yaml annotation, so editors and CI
+tools can validate the file.`,
+		Example: `  # Save the schema for editor integration
+  helm dependency-check schema > dependencies.schema.json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			_, err := os.Stdout.Write(parser.Schema())
+			return err
+		},
+	}
+}
@@ -0,0 +1,76 @@
+package checker
+
+import (
+	"fmt"
+
+	"github.com/Masterminds/semver/v3"
+
+	"helm-depcheck/pkg/types"
+)
+
+// applyAssumptions returns the releases with the assumed charts patched in, for checking
+// what-if scenarios without changing the cluster. An assumption about a deployed release
+// replaces its chart version, and its chart when that differs; one about a release that is
+// not deployed adds it. Patched releases keep their app version only when the chart stays
+// the same, and lose the requirements stored with the previous chart.
+func applyAssumptions(releases []types.Release, assumptions []types.Assumption) []types.Release {
+	if len(assumptions) == 0 {
+		return releases
+	}
+
+	patched := make([]types.Release, 0, len(releases)+len(assumptions))
+	applied := make(map[int]bool)
+	for _, release := range releases {
+		for i, assumption := range assumptions {
+			if assumption.Namespace != release.Namespace || assumption.Release != release.Name {
+				continue
+			}
+
+			appVersion := release.Chart.AppVersion
+			if assumption.Chart != release.Chart.Name {
+				appVersion = ""
+			}
+			release.Chart = types.ChartInfo{
+				Name:       assumption.Chart,
+				Version:    assumption.Version,
+				AppVersion: appVersion,
+			}
+			release.Assumed = true
+			applied[i] = true
+		}
+		patched = append(patched, release)
+	}
+
+	for i, assumption := range assumptions {
+		if applied[i] {
+			continue
+		}
+		patched = append(patched, types.Release{
+			Name:      assumption.Release,
+			Namespace: assumption.Namespace,
+			Chart:     types.ChartInfo{Name: assumption.Chart, Version: assumption.Version},
+			Status:    "deployed",
+			Assumed:   true,
+		})
+	}
+
+	return patched
+}
+
+// validateAssumptions checks that assumed versions are semantic versions and that no
+// release is assumed more than once
+func validateAssumptions(assumptions []types.Assumption) error {
+	seen := make(map[string]bool)
+	for _, assumption := range assumptions {
+		if _, err := semver.NewVersion(assumption.Version); err != nil {
+			return fmt.Errorf("invalid assumption '%s': invalid version: %v", assumption, err)
+		}
+
+		release := assumption.Namespace + "/" + assumption.Release
+		if seen[release] {
+			return fmt.Errorf("invalid assumption '%s': release %s is assumed more than once", assumption, release)
+		}
+		seen[release] = true
+	}
+	return nil
+}
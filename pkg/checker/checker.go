@@ -1,28 +1,38 @@
 package checker
 
 import (
+	"context"
 	"fmt"
 	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/Masterminds/semver/v3"
 
-	"helm-depcheck/pkg/helm"
 	"helm-depcheck/pkg/parser"
 	"helm-depcheck/pkg/types"
 )
 
+// ReleaseSource provides namespaces and deployed releases to the checker
+type ReleaseSource interface {
+	// GetMatchingNamespaces returns namespaces that match the given pattern
+	GetMatchingNamespaces(ctx context.Context, pattern string) ([]string, error)
+	// GetReleases retrieves all deployed releases matching the namespace pattern, along with
+	// the namespaces whose releases could not be listed
+	GetReleases(ctx context.Context, namespacePattern string) ([]types.Release, []types.NamespaceAccessError, error)
+}
+
 // Checker performs dependency compatibility checks
 type Checker struct {
-	helmClient *helm.Client
-	parser     *parser.Parser
+	releaseSource ReleaseSource
+	parser        *parser.Parser
 }
 
 // NewChecker creates a new Checker instance
-func NewChecker(helmClient *helm.Client, parser *parser.Parser) *Checker {
+func NewChecker(releaseSource ReleaseSource, parser *parser.Parser) *Checker {
 	return &Checker{
-		helmClient: helmClient,
-		parser:     parser,
+		releaseSource: releaseSource,
+		parser:        parser,
 	}
 }
 
@@ -32,8 +42,11 @@ func (c *Checker) Check(config types.Config) (*types.CheckResult, error) {
 		Success:           true,
 		Dependencies:      []types.DependencyResult{},
 		Errors:            []types.ValidationError{},
+		Warnings:          []types.ValidationError{},
 		Summary:           types.ResultSummary{},
 		MatchedNamespaces: []string{},
+		TargetNamespace:   config.TargetNamespace,
+		Assumptions:       config.Assumptions,
 	}
 
 	// Validate chart path
@@ -48,28 +61,27 @@ func (c *Checker) Check(config types.Config) (*types.CheckResult, error) {
 		return result, nil
 	}
 
-	// Parse dependencies
-	deps, err := c.parser.ParseDependencies(config.ChartPath)
+	// Parse dependencies, including those of subcharts in recursive mode
+	parseDependencies := c.parser.ParseDependencies
+	if config.Recursive {
+		parseDependencies = c.parser.ParseDependenciesRecursive
+	}
+	deps, err := parseDependencies(config.ChartPath)
 	if err != nil {
 		result.Success = false
-		if validationErr, ok := err.(types.ValidationError); ok {
-			result.Errors = append(result.Errors, validationErr)
-		} else {
-			result.Errors = append(result.Errors, types.NewValidationError(
-				types.ErrorTypeInvalidDependencyFile,
-				"",
-				err.Error(),
-				types.ErrorDetails{},
-			))
-		}
+		result.Errors = types.ValidationErrors(result.Errors).Append(err)
 		return result, nil
 	}
+	result.Chart = c.chartName(config.ChartPath)
 
 	// Use provided namespace pattern or empty string for default behavior
 	namespacePattern := config.NamespacePattern
 
+	ctx, cancel := runContext(config)
+	defer cancel()
+
 	// Get matched namespaces for reporting
-	matchedNamespaces, err := c.getMatchedNamespaces(namespacePattern)
+	matchedNamespaces, err := c.getMatchedNamespaces(ctx, namespacePattern)
 	if err != nil {
 		result.Success = false
 		result.Errors = append(result.Errors, types.NewValidationError(
@@ -82,31 +94,61 @@ func (c *Checker) Check(config types.Config) (*types.CheckResult, error) {
 	}
 	result.MatchedNamespaces = matchedNamespaces
 
-	// If no dependencies, return success
-	if len(deps.Dependencies) == 0 {
+	// If no dependencies or conflicts, return success
+	if len(deps.Dependencies) == 0 && len(deps.Conflicts) == 0 {
 		return result, nil
 	}
 
+	// Fetch releases once and share them between all dependencies
+	listing := c.loadReleases(ctx, config)
+	result.Errors = append(result.Errors, listing.errors...)
+	result.Warnings = append(result.Warnings, listing.warnings...)
+	result.Summary.Warnings += len(listing.warnings)
+	if len(listing.errors) > 0 {
+		result.Success = false
+	}
+	if listing.failed {
+		return result, nil
+	}
+	inventory := newReleaseInventory(listing.releases, config.NamespacePattern)
+
 	// Check each dependency
 	for _, dep := range deps.Dependencies {
-		depResult := c.checkSingleDependency(dep, namespacePattern)
+		depResult := c.checkDependency(dep, inventory, config.TargetNamespace)
 		result.Dependencies = append(result.Dependencies, depResult)
 
 		// Update summary
 		result.Summary.Total++
+		if dep.IsSoft() {
+			result.Summary.Optional++
+		}
+
+		// Unsatisfied optional and warning-severity dependencies don't fail the run
+		if depResult.Status != types.StatusSatisfied && dep.IsSoft() {
+			result.Summary.Warnings++
+			result.Warnings = append(result.Warnings, c.createValidationError(depResult, namespacePattern))
+			continue
+		}
+
 		switch depResult.Status {
 		case types.StatusSatisfied:
 			result.Summary.Satisfied++
 		case types.StatusNotFound:
 			result.Summary.NotFound++
 			result.Success = false
-		case types.StatusVersionMismatch:
+		case types.StatusVersionMismatch, types.StatusAppVersionMismatch:
 			result.Summary.Mismatched++
 			result.Success = false
 		case types.StatusMultipleFound:
 			result.Summary.Multiple++
 			result.Success = false
-		case types.StatusError:
+		case types.StatusNoAlternative:
+			result.Summary.NoAlternative++
+			result.Success = false
+		case types.StatusContradictory:
+			result.Summary.Contradictory++
+			result.Success = false
+		case types.StatusInvalidAppVersion, types.StatusNoTargetNamespace, types.StatusError:
 			result.Summary.Errors++
 			result.Success = false
 		}
@@ -118,40 +160,157 @@ func (c *Checker) Check(config types.Config) (*types.CheckResult, error) {
 		}
 	}
 
+	// Check each conflict rule
+	for _, conflict := range deps.Conflicts {
+		conflictResult := c.checkConflict(conflict, inventory, config.TargetNamespace)
+		result.Conflicts = append(result.Conflicts, conflictResult)
+
+		if conflictResult.Status == types.StatusNoConflict {
+			continue
+		}
+
+		validationError := c.createValidationError(conflictResult, namespacePattern)
+		if conflict.IsSoft() {
+			result.Summary.Warnings++
+			result.Warnings = append(result.Warnings, validationError)
+			continue
+		}
+
+		if conflictResult.Status == types.StatusConflict {
+			result.Summary.Conflicts++
+		} else {
+			result.Summary.Errors++
+		}
+		result.Success = false
+		result.Errors = append(result.Errors, validationError)
+	}
+
+	// Follow the requirements of the releases the chart depends on
+	dependencyGraph := c.buildGraph(result.Chart, deps)
+	if config.Transitive {
+		c.checkTransitive(result, inventory, dependencyGraph, namespacePattern)
+	}
+
+	// Mutual requirements can only be found once all edges are known
+	c.checkCycles(result, dependencyGraph, result.Chart)
+
 	return result, nil
 }
 
+// checkDependency checks a dependency entry, which is either a single dependency or an anyOf group
+func (c *Checker) checkDependency(dep types.Dependency, inventory *releaseInventory, targetNamespace string) types.DependencyResult {
+	if dep.IsGroup() {
+		return c.checkAlternatives(dep, inventory, targetNamespace)
+	}
+	return c.checkSingleDependency(dep, inventory, targetNamespace)
+}
+
+// checkAlternatives checks an anyOf group. The group is satisfied by the first alternative
+// that satisfies its constraints; every alternative is evaluated so the result shows why
+// the others failed.
+func (c *Checker) checkAlternatives(group types.Dependency, inventory *releaseInventory, targetNamespace string) types.DependencyResult {
+	result := types.DependencyResult{
+		Name:         group.DisplayName(),
+		Status:       types.StatusNoAlternative,
+		Optional:     group.Optional,
+		Severity:     group.Severity,
+		Source:       group.Source,
+		Line:         group.Line,
+		Column:       group.Column,
+		DeclaredBy:   group.DeclaredBy,
+		Alternatives: make([]types.DependencyResult, 0, len(group.AnyOf)),
+	}
+
+	for _, alternative := range group.AnyOf {
+		altResult := c.checkSingleDependency(alternative, inventory, targetNamespace)
+		result.Alternatives = append(result.Alternatives, altResult)
+
+		if altResult.Status == types.StatusSatisfied && result.Chosen == "" {
+			result.Status = types.StatusSatisfied
+			result.Chosen = altResult.Name
+			result.FoundReleases = altResult.FoundReleases
+		}
+	}
+
+	if result.Status != types.StatusSatisfied {
+		result.Error = "none of the alternatives satisfies its constraints"
+	}
+
+	return result
+}
+
 // checkSingleDependency checks a single dependency against deployed releases
-func (c *Checker) checkSingleDependency(dep types.Dependency, namespacePattern string) types.DependencyResult {
+func (c *Checker) checkSingleDependency(dep types.Dependency, inventory *releaseInventory, targetNamespace string) types.DependencyResult {
 	result := types.DependencyResult{
-		Name:            dep.Name,
-		RequiredVersion: dep.Version,
-		Status:          types.StatusError,
-		FoundReleases:   []types.Release{},
+		Name:               dep.Name,
+		RequiredVersion:    dep.Version,
+		RequiredAppVersion: dep.AppVersion,
+		Status:             types.StatusError,
+		Scope:              dep.EffectiveScope(),
+		Optional:           dep.Optional,
+		Severity:           dep.Severity,
+		Source:             dep.Source,
+		Line:               dep.Line,
+		Column:             dep.Column,
+		Namespace:          dep.Namespace,
+		NamespacePattern:   dep.NamespacePattern,
+		ReleaseName:        dep.ReleaseName,
+		ReleaseNamePattern: dep.ReleaseNamePattern,
+		DeclaredBy:         dep.DeclaredBy,
+		FoundReleases:      []types.Release{},
 	}
 
-	// Find releases for this chart
-	releases, err := c.helmClient.FindReleasesByChartName(dep.Name, namespacePattern)
+	if result.Scope == types.ScopeSameNamespace {
+		result.Namespace = targetNamespace
+	}
+
+	// Constraints merged from several charts may contradict each other
+	if len(dep.DeclaredBy) > 1 {
+		if err := c.checkSatisfiable(dep); err != nil {
+			result.Status = types.StatusContradictory
+			result.Error = err.Error()
+			return result
+		}
+	}
+
+	filter, err := newReleaseFilter(dep, targetNamespace)
 	if err != nil {
-		result.Error = fmt.Sprintf("failed to find releases: %v", err)
+		if err == errNoTargetNamespace {
+			result.Status = types.StatusNoTargetNamespace
+		}
+		result.Error = err.Error()
 		return result
 	}
 
+	// Find releases for this chart that satisfy the dependency's namespace and release
+	// name constraints, so duplicate detection only sees the releases that count
+	releases := filter.apply(inventory.releasesForChart(dep.Name))
+
 	// No releases found
 	if len(releases) == 0 {
 		result.Status = types.StatusNotFound
+		// A namespace excluded by the run's pattern was never searched, so say so
+		if filter.namespace != "" && !inventory.isSearched(filter.namespace) {
+			result.Error = fmt.Sprintf("namespace %s is outside the namespaces searched (--namespace-pattern, system namespaces are excluded by default)", filter.namespace)
+		}
 		return result
 	}
 
-	// Group releases by namespace to detect duplicates
+	// Group releases by namespace to detect duplicates, in sorted order so the namespace
+	// reported is deterministic
 	releasesByNamespace := make(map[string][]types.Release)
+	var namespaces []string
 	for _, release := range releases {
+		if _, ok := releasesByNamespace[release.Namespace]; !ok {
+			namespaces = append(namespaces, release.Namespace)
+		}
 		releasesByNamespace[release.Namespace] = append(releasesByNamespace[release.Namespace], release)
 	}
+	sort.Strings(namespaces)
 
 	// Check for duplicates in same namespace
-	for namespace, nsReleases := range releasesByNamespace {
-		if len(nsReleases) > 1 {
+	for _, namespace := range namespaces {
+		if nsReleases := releasesByNamespace[namespace]; len(nsReleases) > 1 {
 			result.Status = types.StatusMultipleFound
 			result.FoundReleases = nsReleases
 			result.Error = fmt.Sprintf("multiple instances found in namespace %s", namespace)
@@ -160,13 +319,9 @@ func (c *Checker) checkSingleDependency(dep types.Dependency, namespacePattern s
 	}
 
 	// Check for multiple namespaces
-	if len(releasesByNamespace) > 1 {
+	if len(namespaces) > 1 {
 		result.Status = types.StatusMultipleFound
 		result.FoundReleases = releases
-		namespaces := make([]string, 0, len(releasesByNamespace))
-		for ns := range releasesByNamespace {
-			namespaces = append(namespaces, ns)
-		}
 		result.Error = fmt.Sprintf("found in multiple namespaces: %s", strings.Join(namespaces, ", "))
 		return result
 	}
@@ -182,10 +337,101 @@ func (c *Checker) checkSingleDependency(dep types.Dependency, namespacePattern s
 		return result
 	}
 
-	if compatible {
-		result.Status = types.StatusSatisfied
-	} else {
+	if !compatible {
 		result.Status = types.StatusVersionMismatch
+		return result
+	}
+
+	// Check the application version with the same constraint engine
+	if dep.AppVersion != "" {
+		if _, err := semver.NewVersion(release.Chart.AppVersion); err != nil {
+			result.Status = types.StatusInvalidAppVersion
+			result.Error = fmt.Sprintf("app version %q of release %s/%s is not a semantic version",
+				release.Chart.AppVersion, release.Namespace, release.Name)
+			return result
+		}
+
+		compatible, err := c.isVersionCompatible(release.Chart.AppVersion, dep.AppVersion)
+		if err != nil {
+			result.Status = types.StatusError
+			result.Error = fmt.Sprintf("app version compatibility check failed: %v", err)
+			return result
+		}
+
+		if !compatible {
+			result.Status = types.StatusAppVersionMismatch
+			result.Error = fmt.Sprintf("app version %s does not satisfy %s", release.Chart.AppVersion, dep.AppVersion)
+			return result
+		}
+	}
+
+	result.Status = types.StatusSatisfied
+	return result
+}
+
+// checkConflict checks that no deployed release matches a conflict rule
+func (c *Checker) checkConflict(conflict types.Conflict, inventory *releaseInventory, targetNamespace string) types.DependencyResult {
+	result := types.DependencyResult{
+		Name:               conflict.Name,
+		RequiredVersion:    conflict.Version,
+		RequiredAppVersion: conflict.AppVersion,
+		Status:             types.StatusError,
+		Scope:              conflict.EffectiveScope(),
+		Severity:           conflict.Severity,
+		Namespace:          conflict.Namespace,
+		NamespacePattern:   conflict.NamespacePattern,
+		ReleaseName:        conflict.ReleaseName,
+		ReleaseNamePattern: conflict.ReleaseNamePattern,
+		Reason:             conflict.Reason,
+		Source:             conflict.Source,
+		Line:               conflict.Line,
+		Column:             conflict.Column,
+		DeclaredBy:         conflict.DeclaredBy,
+		FoundReleases:      []types.Release{},
+	}
+	if result.RequiredVersion == "" {
+		result.RequiredVersion = "*"
+	}
+
+	if result.Scope == types.ScopeSameNamespace {
+		result.Namespace = targetNamespace
+	}
+
+	filter, err := newReleaseFilter(conflict.Dependency, targetNamespace)
+	if err != nil {
+		if err == errNoTargetNamespace {
+			result.Status = types.StatusNoTargetNamespace
+		}
+		result.Error = err.Error()
+		return result
+	}
+
+	// Without a version constraint every release of the chart conflicts, including
+	// prereleases that "*" would not match. Releases whose app version is not a semantic
+	// version can't match an app version constraint, e.g. charts without an appVersion,
+	// so they are skipped rather than failing the whole rule.
+	for _, release := range filter.apply(inventory.releasesForChart(conflict.Name)) {
+		if conflict.Version != "" {
+			compatible, err := c.isVersionCompatible(release.Chart.Version, conflict.Version)
+			if err != nil || !compatible {
+				continue
+			}
+		}
+
+		if conflict.AppVersion != "" {
+			compatible, err := c.isVersionCompatible(release.Chart.AppVersion, conflict.AppVersion)
+			if err != nil || !compatible {
+				continue
+			}
+		}
+
+		result.FoundReleases = append(result.FoundReleases, release)
+	}
+
+	if len(result.FoundReleases) > 0 {
+		result.Status = types.StatusConflict
+	} else {
+		result.Status = types.StatusNoConflict
 	}
 
 	return result
@@ -212,13 +458,36 @@ func (c *Checker) isVersionCompatible(foundVersion, requiredConstraint string) (
 func (c *Checker) createValidationError(depResult types.DependencyResult, namespacePattern string) types.ValidationError {
 	switch depResult.Status {
 	case types.StatusNotFound:
+		searchPattern := namespacePattern
+		if depResult.NamespacePattern != "" {
+			searchPattern = depResult.NamespacePattern
+		}
 		return types.NewValidationError(
 			types.ErrorTypeDependencyNotFound,
 			depResult.Name,
 			"No releases found matching the dependency requirements",
 			types.ErrorDetails{
 				RequiredVersion: depResult.RequiredVersion,
-				SearchPattern:   namespacePattern,
+				Namespace:       depResult.Namespace,
+				Release:         depResult.ReleaseName,
+				SearchPattern:   searchPattern,
+				Hint:            depResult.Error,
+			},
+		)
+
+	case types.StatusAppVersionMismatch:
+		release := depResult.FoundReleases[0]
+		return types.NewValidationError(
+			types.ErrorTypeAppVersionMismatch,
+			depResult.Name,
+			"Deployed app version does not satisfy app version constraint",
+			types.ErrorDetails{
+				RequiredVersion:    depResult.RequiredVersion,
+				FoundVersion:       release.Chart.Version,
+				RequiredAppVersion: depResult.RequiredAppVersion,
+				FoundAppVersion:    release.Chart.AppVersion,
+				Namespace:          release.Namespace,
+				Release:            release.Name,
 			},
 		)
 
@@ -269,6 +538,85 @@ func (c *Checker) createValidationError(depResult types.DependencyResult, namesp
 			)
 		}
 
+	case types.StatusConflict:
+		releases := make([]string, len(depResult.FoundReleases))
+		for i, release := range depResult.FoundReleases {
+			releases[i] = fmt.Sprintf("%s/%s@%s", release.Namespace, release.Name, release.Chart.Version)
+		}
+		return types.NewValidationError(
+			types.ErrorTypeConflictDetected,
+			depResult.Name,
+			depResult.Reason,
+			types.ErrorDetails{
+				RequiredVersion:    depResult.RequiredVersion,
+				RequiredAppVersion: depResult.RequiredAppVersion,
+				Namespace:          depResult.Namespace,
+				FoundReleases:      releases,
+			},
+		)
+
+	case types.StatusNoAlternative:
+		alternatives := make([]string, len(depResult.Alternatives))
+		for i, alternative := range depResult.Alternatives {
+			alternatives[i] = fmt.Sprintf("%s %s: %s", alternative.Name, alternative.RequiredVersion, alternative.Status)
+			if alternative.Error != "" {
+				alternatives[i] += fmt.Sprintf(" (%s)", alternative.Error)
+			}
+		}
+		return types.NewValidationError(
+			types.ErrorTypeNoAlternativeSatisfied,
+			depResult.Name,
+			"None of the alternatives satisfies its constraints",
+			types.ErrorDetails{
+				Alternatives: alternatives,
+			},
+		)
+
+	case types.StatusContradictory:
+		declarations := make([]string, len(depResult.DeclaredBy))
+		for i, declaration := range depResult.DeclaredBy {
+			declarations[i] = fmt.Sprintf("%s requires %s", declaration.Chart, declaration.Version)
+			if declaration.AppVersion != "" {
+				declarations[i] += fmt.Sprintf(" (appVersion %s)", declaration.AppVersion)
+			}
+		}
+		return types.NewValidationError(
+			types.ErrorTypeContradictoryConstraints,
+			depResult.Name,
+			depResult.Error,
+			types.ErrorDetails{
+				RequiredVersion:    depResult.RequiredVersion,
+				RequiredAppVersion: depResult.RequiredAppVersion,
+				DeclaredBy:         declarations,
+			},
+		)
+
+	case types.StatusNoTargetNamespace:
+		return types.NewValidationError(
+			types.ErrorTypeNoTargetNamespace,
+			depResult.Name,
+			depResult.Error,
+			types.ErrorDetails{
+				File:   depResult.Source,
+				Line:   depResult.Line,
+				Column: depResult.Column,
+			},
+		)
+
+	case types.StatusInvalidAppVersion:
+		release := depResult.FoundReleases[0]
+		return types.NewValidationError(
+			types.ErrorTypeInvalidAppVersion,
+			depResult.Name,
+			"Deployed app version is not a semantic version and cannot be checked against a constraint",
+			types.ErrorDetails{
+				RequiredAppVersion: depResult.RequiredAppVersion,
+				FoundAppVersion:    release.Chart.AppVersion,
+				Namespace:          release.Namespace,
+				Release:            release.Name,
+			},
+		)
+
 	default:
 		return types.NewValidationError(
 			types.ErrorTypeHelmClientError,
@@ -287,6 +635,11 @@ func (c *Checker) ValidateConfig(config types.Config) error {
 		return fmt.Errorf("chart path is required")
 	}
 
+	return c.validateCommonConfig(config)
+}
+
+// validateCommonConfig validates the configuration shared by all checks
+func (c *Checker) validateCommonConfig(config types.Config) error {
 	// Validate namespace pattern if provided
 	if config.NamespacePattern != "" {
 		if err := c.validateNamespacePattern(config.NamespacePattern); err != nil {
@@ -294,6 +647,18 @@ func (c *Checker) ValidateConfig(config types.Config) error {
 		}
 	}
 
+	if config.Concurrency < 0 {
+		return fmt.Errorf("invalid concurrency %d: must not be negative", config.Concurrency)
+	}
+
+	if config.Timeout < 0 {
+		return fmt.Errorf("invalid timeout %s: must not be negative", config.Timeout)
+	}
+
+	if err := validateAssumptions(config.Assumptions); err != nil {
+		return err
+	}
+
 	// Validate output format
 	switch types.OutputFormat(config.OutputFormat) {
 	case types.OutputFormatText, types.OutputFormatJSON, types.OutputFormatYAML, "":
@@ -312,8 +677,8 @@ func (c *Checker) validateNamespacePattern(pattern string) error {
 }
 
 // getMatchedNamespaces returns namespaces that match the given pattern
-func (c *Checker) getMatchedNamespaces(namespacePattern string) ([]string, error) {
-	return c.helmClient.GetMatchingNamespaces(namespacePattern)
+func (c *Checker) getMatchedNamespaces(ctx context.Context, namespacePattern string) ([]string, error) {
+	return c.releaseSource.GetMatchingNamespaces(ctx, namespacePattern)
 }
 
 // GetSupportedVersionOperators returns a list of supported version operators
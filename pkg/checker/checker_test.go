@@ -0,0 +1,470 @@
+package checker
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"helm-depcheck/pkg/helm/fake"
+	"helm-depcheck/pkg/parser"
+	"helm-depcheck/pkg/types"
+)
+
+// writeChart creates a chart directory named app with the given dependencies.yaml
+func writeChart(t *testing.T, dependencies string) string {
+	t.Helper()
+
+	dir := filepath.Join(t.TempDir(), "app")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"Chart.yaml":        "apiVersion: v2\nname: app\nversion: 1.0.0\n",
+		"dependencies.yaml": dependencies,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// release creates a deployed release of the given chart
+func release(namespace, name, chart, version, appVersion string) types.Release {
+	return types.Release{
+		Name:      name,
+		Namespace: namespace,
+		Status:    "deployed",
+		Chart:     types.ChartInfo{Name: chart, Version: version, AppVersion: appVersion},
+	}
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name            string
+		dependencies    string
+		releases        []types.Release
+		unreadable      []string
+		strictAccess    bool
+		targetNamespace string
+		assumptions     []types.Assumption
+		wantSuccess     bool
+		wantStatuses    []string
+		wantConflicts   []string
+		wantWarnings    int
+	}{
+		{
+			name:         "satisfied",
+			dependencies: "dependencies:\n  - name: redis\n    version: ^17.0.0\n",
+			releases:     []types.Release{release("shared", "redis", "redis", "17.3.0", "")},
+			wantSuccess:  true,
+			wantStatuses: []string{types.StatusSatisfied},
+		},
+		{
+			name:         "version mismatch",
+			dependencies: "dependencies:\n  - name: redis\n    version: ^17.0.0\n",
+			releases:     []types.Release{release("shared", "redis", "redis", "16.1.0", "")},
+			wantStatuses: []string{types.StatusVersionMismatch},
+		},
+		{
+			name:         "not found",
+			dependencies: "dependencies:\n  - name: redis\n    version: ^17.0.0\n",
+			wantStatuses: []string{types.StatusNotFound},
+		},
+		{
+			name:         "found in multiple namespaces",
+			dependencies: "dependencies:\n  - name: redis\n    version: ^17.0.0\n",
+			releases: []types.Release{
+				release("team-a", "redis", "redis", "17.3.0", ""),
+				release("team-b", "redis", "redis", "17.3.0", ""),
+			},
+			wantStatuses: []string{types.StatusMultipleFound},
+		},
+		{
+			name:         "only deployed releases count",
+			dependencies: "dependencies:\n  - name: redis\n    version: ^17.0.0\n",
+			releases: []types.Release{
+				{Name: "redis", Namespace: "shared", Status: "failed", Chart: types.ChartInfo{Name: "redis", Version: "17.3.0"}},
+			},
+			wantStatuses: []string{types.StatusNotFound},
+		},
+		{
+			name:         "app version satisfied",
+			dependencies: "dependencies:\n  - name: postgresql\n    version: '>=12.0.0'\n    appVersion: ^15.0.0\n",
+			releases:     []types.Release{release("data", "db", "postgresql", "12.5.0", "15.4.0")},
+			wantSuccess:  true,
+			wantStatuses: []string{types.StatusSatisfied},
+		},
+		{
+			name:         "app version mismatch",
+			dependencies: "dependencies:\n  - name: postgresql\n    version: '>=12.0.0'\n    appVersion: ^15.0.0\n",
+			releases:     []types.Release{release("data", "db", "postgresql", "12.5.0", "14.9.0")},
+			wantStatuses: []string{types.StatusAppVersionMismatch},
+		},
+		{
+			name:         "app version not semver",
+			dependencies: "dependencies:\n  - name: postgresql\n    version: '>=12.0.0'\n    appVersion: ^15.0.0\n",
+			releases:     []types.Release{release("data", "db", "postgresql", "12.5.0", "latest")},
+			wantStatuses: []string{types.StatusInvalidAppVersion},
+		},
+		{
+			name:         "optional dependency only warns",
+			dependencies: "dependencies:\n  - name: redis\n    version: ^17.0.0\n    optional: true\n",
+			wantSuccess:  true,
+			wantStatuses: []string{types.StatusNotFound},
+			wantWarnings: 1,
+		},
+		{
+			name:         "warning severity only warns",
+			dependencies: "dependencies:\n  - name: redis\n    version: ^17.0.0\n    severity: warning\n",
+			releases:     []types.Release{release("shared", "redis", "redis", "16.1.0", "")},
+			wantSuccess:  true,
+			wantStatuses: []string{types.StatusVersionMismatch},
+			wantWarnings: 1,
+		},
+		{
+			name:         "unreadable namespace warns",
+			dependencies: "dependencies:\n  - name: redis\n    version: ^17.0.0\n",
+			releases:     []types.Release{release("shared", "redis", "redis", "17.3.0", "")},
+			unreadable:   []string{"restricted"},
+			wantSuccess:  true,
+			wantStatuses: []string{types.StatusSatisfied},
+			wantWarnings: 1,
+		},
+		{
+			name:         "unreadable namespace fails with strict access",
+			dependencies: "dependencies:\n  - name: redis\n    version: ^17.0.0\n",
+			releases:     []types.Release{release("shared", "redis", "redis", "17.3.0", "")},
+			unreadable:   []string{"restricted"},
+			strictAccess: true,
+			wantStatuses: []string{types.StatusSatisfied},
+		},
+		{
+			name:            "same namespace",
+			dependencies:    "dependencies:\n  - name: redis\n    version: ^17.0.0\n    scope: same-namespace\n",
+			releases:        []types.Release{release("team-a", "redis", "redis", "17.3.0", ""), release("team-b", "redis", "redis", "16.0.0", "")},
+			targetNamespace: "team-a",
+			wantSuccess:     true,
+			wantStatuses:    []string{types.StatusSatisfied},
+		},
+		{
+			name:         "same namespace without target namespace",
+			dependencies: "dependencies:\n  - name: redis\n    version: ^17.0.0\n    scope: same-namespace\n  - name: postgresql\n    version: ^15.0.0\n",
+			releases:     []types.Release{release("data", "db", "postgresql", "15.4.0", "")},
+			wantStatuses: []string{types.StatusNoTargetNamespace, types.StatusSatisfied},
+		},
+		{
+			name:          "conflict detected",
+			dependencies:  "conflicts:\n  - name: legacy\n    appVersion: <2.0.0\n",
+			releases:      []types.Release{release("apps", "old", "legacy", "1.0.0", "1.5.0")},
+			wantConflicts: []string{types.StatusConflict},
+		},
+		{
+			name:          "conflict without version matches prereleases",
+			dependencies:  "conflicts:\n  - name: legacy-auth\n",
+			releases:      []types.Release{release("auth", "legacy-auth", "legacy-auth", "2.0.0-beta.1", "")},
+			wantConflicts: []string{types.StatusConflict},
+		},
+		{
+			name:         "conflict skips releases without app version",
+			dependencies: "conflicts:\n  - name: legacy\n    appVersion: <2.0.0\n",
+			releases: []types.Release{
+				release("apps", "old", "legacy", "1.0.0", ""),
+				release("apps", "older", "legacy", "1.0.0", "1.5.0"),
+			},
+			wantConflicts: []string{types.StatusConflict},
+		},
+		{
+			name:          "conflict without matching release",
+			dependencies:  "conflicts:\n  - name: legacy\n    appVersion: <2.0.0\n",
+			releases:      []types.Release{release("apps", "new", "legacy", "2.0.0", "2.1.0")},
+			wantSuccess:   true,
+			wantConflicts: []string{types.StatusNoConflict},
+		},
+		{
+			name:         "assumed upgrade",
+			dependencies: "dependencies:\n  - name: redis\n    version: ^17.0.0\n",
+			releases:     []types.Release{release("shared", "redis", "redis", "17.3.0", "")},
+			assumptions:  []types.Assumption{{Namespace: "shared", Release: "redis", Chart: "redis", Version: "18.0.0"}},
+			wantStatuses: []string{types.StatusVersionMismatch},
+		},
+		{
+			name:         "assumed install",
+			dependencies: "dependencies:\n  - name: redis\n    version: ^17.0.0\n",
+			assumptions:  []types.Assumption{{Namespace: "shared", Release: "redis", Chart: "redis", Version: "17.3.0"}},
+			wantSuccess:  true,
+			wantStatuses: []string{types.StatusSatisfied},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := fake.NewReleaseSource(nil, tt.releases)
+			for _, namespace := range tt.unreadable {
+				source.SetUnreadable(namespace)
+			}
+			checker := NewChecker(source, parser.NewParser())
+
+			result, err := checker.Check(types.Config{
+				ChartPath:       writeChart(t, tt.dependencies),
+				StrictAccess:    tt.strictAccess,
+				TargetNamespace: tt.targetNamespace,
+				Assumptions:     tt.assumptions,
+			})
+			if err != nil {
+				t.Fatalf("Check() error = %v", err)
+			}
+
+			if result.Success != tt.wantSuccess {
+				t.Errorf("Success = %v, want %v (errors: %v)", result.Success, tt.wantSuccess, result.Errors)
+			}
+			if len(result.Dependencies) != len(tt.wantStatuses) {
+				t.Fatalf("got %d dependency results, want %d", len(result.Dependencies), len(tt.wantStatuses))
+			}
+			for i, want := range tt.wantStatuses {
+				if got := result.Dependencies[i].Status; got != want {
+					t.Errorf("dependency %s status = %s, want %s", result.Dependencies[i].Name, got, want)
+				}
+			}
+			if len(result.Conflicts) != len(tt.wantConflicts) {
+				t.Fatalf("got %d conflict results, want %d", len(result.Conflicts), len(tt.wantConflicts))
+			}
+			for i, want := range tt.wantConflicts {
+				if got := result.Conflicts[i].Status; got != want {
+					t.Errorf("conflict %s status = %s, want %s", result.Conflicts[i].Name, got, want)
+				}
+			}
+			if result.Summary.Warnings != tt.wantWarnings || len(result.Warnings) != tt.wantWarnings {
+				t.Errorf("warnings = %d (summary %d), want %d", len(result.Warnings), result.Summary.Warnings, tt.wantWarnings)
+			}
+		})
+	}
+}
+
+func TestCheckMultipleFoundNamesNamespacesInOrder(t *testing.T) {
+	tests := []struct {
+		name      string
+		releases  []types.Release
+		wantError string
+	}{
+		{
+			name: "multiple namespaces",
+			releases: []types.Release{
+				release("c", "redis", "redis", "17.3.0", ""),
+				release("a", "redis", "redis", "17.3.0", ""),
+				release("b", "redis", "redis", "17.3.0", ""),
+			},
+			wantError: "found in multiple namespaces: a, b, c",
+		},
+		{
+			name: "duplicates in several namespaces",
+			releases: []types.Release{
+				release("c", "redis", "redis", "17.3.0", ""),
+				release("c", "cache", "redis", "17.3.0", ""),
+				release("b", "redis", "redis", "17.3.0", ""),
+				release("b", "cache", "redis", "17.3.0", ""),
+			},
+			wantError: "multiple instances found in namespace b",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker := NewChecker(fake.NewReleaseSource(nil, tt.releases), parser.NewParser())
+			dir := writeChart(t, "dependencies:\n  - name: redis\n    version: ^17.0.0\n")
+
+			// Map iteration order varies between runs, so check a few times
+			for range 10 {
+				result, err := checker.Check(types.Config{ChartPath: dir})
+				if err != nil {
+					t.Fatalf("Check() error = %v", err)
+				}
+				if got := result.Dependencies[0].Error; got != tt.wantError {
+					t.Fatalf("error = %q, want %q", got, tt.wantError)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckMismatchErrorTypes(t *testing.T) {
+	source := fake.NewReleaseSource(nil, []types.Release{
+		release("shared", "redis", "redis", "16.1.0", "6.2.0"),
+		release("data", "db", "postgresql", "15.4.0", "14.9.0"),
+	})
+	checker := NewChecker(source, parser.NewParser())
+
+	result, err := checker.Check(types.Config{
+		ChartPath: writeChart(t, "dependencies:\n  - name: redis\n    version: ^17.0.0\n    appVersion: ^7.0.0\n"+
+			"  - name: postgresql\n    version: ^15.0.0\n    appVersion: ^15.0.0\n"),
+	})
+	if err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+
+	if result.Summary.Mismatched != 2 || len(result.Errors) != 2 {
+		t.Fatalf("errors = %v (mismatched %d), want both dependencies mismatched", result.Errors, result.Summary.Mismatched)
+	}
+	for i, want := range []types.ErrorType{types.ErrorTypeVersionMismatch, types.ErrorTypeAppVersionMismatch} {
+		if got := result.Errors[i].Type; got != want {
+			t.Errorf("error %d type = %s, want %s", i, got, want)
+		}
+	}
+}
+
+func TestCheckConflictReportsMatchingReleases(t *testing.T) {
+	source := fake.NewReleaseSource(nil, []types.Release{
+		release("apps", "old", "legacy", "1.0.0", ""),
+		release("apps", "older", "legacy", "1.0.0", "1.5.0"),
+	})
+	checker := NewChecker(source, parser.NewParser())
+
+	result, err := checker.Check(types.Config{
+		ChartPath: writeChart(t, "conflicts:\n  - name: legacy\n    appVersion: <2.0.0\n"),
+	})
+	if err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+
+	if len(result.Conflicts) != 1 || result.Conflicts[0].Status != types.StatusConflict {
+		t.Fatalf("conflicts = %+v, want a single conflict", result.Conflicts)
+	}
+	found := result.Conflicts[0].FoundReleases
+	if len(found) != 1 || found[0].Name != "older" {
+		t.Errorf("found releases = %+v, want only apps/older", found)
+	}
+	if len(result.Errors) != 1 || !strings.Contains(result.Errors[0].Error(), "appVersion: <2.0.0") {
+		t.Errorf("errors = %v, want the forbidden app version reported", result.Errors)
+	}
+}
+
+func TestCheckRecursiveContradictory(t *testing.T) {
+	dir := writeChart(t, "dependencies:\n  - name: redis\n    version: ^17.0.0\n")
+	subchart := filepath.Join(dir, "charts", "cache")
+	if err := os.MkdirAll(subchart, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"Chart.yaml":        "apiVersion: v2\nname: cache\nversion: 1.0.0\n",
+		"dependencies.yaml": "dependencies:\n  - name: redis\n    version: ^18.0.0\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(subchart, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	source := fake.NewReleaseSource(nil, []types.Release{release("shared", "redis", "redis", "17.3.0", "")})
+	checker := NewChecker(source, parser.NewParser())
+
+	result, err := checker.Check(types.Config{ChartPath: dir, Recursive: true})
+	if err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+
+	if result.Success || len(result.Dependencies) != 1 || result.Dependencies[0].Status != types.StatusContradictory {
+		t.Fatalf("dependencies = %+v, want a single contradictory dependency", result.Dependencies)
+	}
+	if result.Summary.Contradictory != 1 {
+		t.Errorf("contradictory = %d, want 1", result.Summary.Contradictory)
+	}
+}
+
+func TestCheckNoTargetNamespace(t *testing.T) {
+	checker := NewChecker(fake.NewReleaseSource(nil, nil), parser.NewParser())
+
+	dir := writeChart(t, "dependencies:\n  - name: redis\n    version: ^17.0.0\n    scope: same-namespace\n"+
+		"conflicts:\n  - name: memcached\n    scope: same-namespace\n")
+	result, err := checker.Check(types.Config{ChartPath: dir})
+	if err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+
+	if result.Success || result.Summary.Errors != 2 || len(result.Errors) != 2 {
+		t.Fatalf("errors = %v (summary %d), want one for the dependency and one for the conflict", result.Errors, result.Summary.Errors)
+	}
+	file := filepath.Join(dir, "dependencies.yaml")
+	for i, want := range []string{
+		"Target namespace required: redis (" + file + ", line 2, column 11)",
+		"Target namespace required: memcached (" + file + ", line 6, column 11)",
+	} {
+		if got := result.Errors[i].Error(); !strings.HasPrefix(got, want) {
+			t.Errorf("error %d = %q, want it to start with %q", i, got, want)
+		}
+	}
+}
+
+func TestCheckNamespaceOutsidePattern(t *testing.T) {
+	source := fake.NewReleaseSource(nil, []types.Release{
+		release("kube-system", "metrics", "metrics-server", "3.12.0", ""),
+	})
+	checker := NewChecker(source, parser.NewParser())
+
+	result, err := checker.Check(types.Config{
+		ChartPath: writeChart(t, "dependencies:\n  - name: metrics-server\n    version: ^3.0.0\n    namespace: kube-system\n"),
+	})
+	if err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+
+	if len(result.Errors) != 1 || !strings.Contains(result.Errors[0].Error(), "outside the namespaces searched") {
+		t.Errorf("errors = %v, want the namespace reported as not searched", result.Errors)
+	}
+}
+
+func TestCheckReverse(t *testing.T) {
+	api := release("apps", "api", "api", "1.2.0", "")
+	api.Chart.DependenciesFile = []byte("dependencies:\n  - name: postgresql\n    version: ^15.0.0\n")
+	source := fake.NewReleaseSource(nil, []types.Release{
+		api,
+		release("data", "db", "postgresql", "15.4.0", "15.4"),
+	})
+	checker := NewChecker(source, parser.NewParser())
+
+	tests := []struct {
+		name        string
+		config      types.Config
+		wantSuccess bool
+		wantBroken  int
+	}{
+		{
+			name:        "compatible upgrade",
+			config:      types.Config{ReverseChart: "postgresql", ToVersion: "15.9.0"},
+			wantSuccess: true,
+		},
+		{
+			name:       "breaking upgrade",
+			config:     types.Config{ReverseChart: "postgresql", ToVersion: "16.0.0"},
+			wantBroken: 1,
+		},
+		{
+			name:       "uninstall",
+			config:     types.Config{ReverseChart: "postgresql", Uninstall: true},
+			wantBroken: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checker.ValidateReverseConfig(tt.config); err != nil {
+				t.Fatalf("ValidateReverseConfig() error = %v", err)
+			}
+
+			result, err := checker.CheckReverse(tt.config)
+			if err != nil {
+				t.Fatalf("CheckReverse() error = %v", err)
+			}
+
+			if result.Success != tt.wantSuccess {
+				t.Errorf("Success = %v, want %v", result.Success, tt.wantSuccess)
+			}
+			if result.Summary.Dependents != 1 {
+				t.Errorf("dependents = %d, want 1", result.Summary.Dependents)
+			}
+			if result.Summary.Broken != tt.wantBroken {
+				t.Errorf("broken = %d, want %d", result.Summary.Broken, tt.wantBroken)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package checker
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+
+	"helm-depcheck/pkg/types"
+)
+
+// versionLiteralPattern matches the (possibly partial) versions mentioned in a constraint
+var versionLiteralPattern = regexp.MustCompile(`\d+(\.\d+)?(\.\d+)?(-[0-9A-Za-z.-]+)?`)
+
+// checkSatisfiable reports an error when the version or app version constraints merged from
+// several charts cannot be satisfied by any version at all
+func (c *Checker) checkSatisfiable(dep types.Dependency) error {
+	satisfiable, err := isSatisfiable(dep.Version)
+	if err != nil {
+		return err
+	}
+	if !satisfiable {
+		return fmt.Errorf("no version satisfies %s", describeDeclarations(dep.DeclaredBy, false))
+	}
+
+	if dep.AppVersion == "" {
+		return nil
+	}
+
+	satisfiable, err = isSatisfiable(dep.AppVersion)
+	if err != nil {
+		return err
+	}
+	if !satisfiable {
+		return fmt.Errorf("no app version satisfies %s", describeDeclarations(dep.DeclaredBy, true))
+	}
+
+	return nil
+}
+
+// isSatisfiable reports whether any version satisfies the constraint. The lowest version
+// accepted by an alternative of the constraint is either 0.0.0 or the first version after
+// one of its bounds, so it is enough to try the versions mentioned in the constraint along
+// with their next patch, minor and major releases.
+func isSatisfiable(constraint string) (bool, error) {
+	parsed, err := semver.NewConstraint(constraint)
+	if err != nil {
+		return false, fmt.Errorf("invalid version constraint '%s': %v", constraint, err)
+	}
+
+	candidates := []semver.Version{*semver.MustParse("0.0.0")}
+	for _, literal := range versionLiteralPattern.FindAllString(constraint, -1) {
+		version, err := semver.NewVersion(literal)
+		if err != nil {
+			continue
+		}
+		candidates = append(candidates, *version, version.IncPatch(), version.IncMinor(), version.IncMajor())
+	}
+
+	for i := range candidates {
+		if parsed.Check(&candidates[i]) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// describeDeclarations lists the constraint each chart declared, e.g.
+// "^1.0.0 (platform/api) and ^2.0.0 (platform/worker)"
+func describeDeclarations(declarations []types.Declaration, appVersion bool) string {
+	parts := make([]string, 0, len(declarations))
+	for _, declaration := range declarations {
+		constraint := declaration.Version
+		if appVersion {
+			constraint = declaration.AppVersion
+		}
+		if constraint == "" {
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("%s (%s)", constraint, declaration.Chart))
+	}
+	return strings.Join(parts, " and ")
+}
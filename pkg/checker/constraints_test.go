@@ -0,0 +1,29 @@
+package checker
+
+import "testing"
+
+func TestIsSatisfiable(t *testing.T) {
+	tests := []struct {
+		constraint string
+		want       bool
+	}{
+		{constraint: "^1.0.0", want: true},
+		{constraint: "^1.0.0, ^2.0.0", want: false},
+		{constraint: ">=1.2.0, <1.3.0", want: true},
+		{constraint: ">1.2.0, <1.2.1", want: false},
+		{constraint: "^1.0.0, ^2.0.0 || ^2.1.0", want: true},
+		{constraint: "~1.4, >=1.4.5", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.constraint, func(t *testing.T) {
+			got, err := isSatisfiable(tt.constraint)
+			if err != nil {
+				t.Fatalf("isSatisfiable() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("isSatisfiable(%q) = %v, want %v", tt.constraint, got, tt.want)
+			}
+		})
+	}
+}
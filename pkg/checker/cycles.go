@@ -0,0 +1,63 @@
+package checker
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"helm-depcheck/pkg/graph"
+	"helm-depcheck/pkg/types"
+)
+
+// buildGraph creates the requirement graph of the chart named root. Requirements merged from subcharts
+// in recursive mode add an edge from every chart that declared them.
+func (c *Checker) buildGraph(root string, deps *types.DependenciesFile) *graph.Graph {
+	dependencyGraph := graph.New()
+	dependencyGraph.AddNode(root)
+
+	for _, dep := range deps.Dependencies {
+		if len(dep.DeclaredBy) == 0 {
+			dependencyGraph.AddDependency(root, dep)
+			continue
+		}
+		for _, declaration := range dep.DeclaredBy {
+			// Declarations are labelled by their subchart path, e.g. "platform/api"
+			chart := declaration.Chart[strings.LastIndex(declaration.Chart, "/")+1:]
+			dependencyGraph.AddDependency(chart, dep)
+		}
+	}
+
+	return dependencyGraph
+}
+
+// checkCycles reports the cycles of the requirement graph. A cycle through the checked
+// chart fails the check, since none of its charts can be installed first; cycles among
+// deployed releases already work in the cluster and only produce warnings.
+func (c *Checker) checkCycles(result *types.CheckResult, dependencyGraph *graph.Graph, root string) {
+	for _, cycle := range dependencyGraph.Cycles() {
+		validationError := types.NewValidationError(
+			types.ErrorTypeDependencyCycle,
+			cycle[0],
+			"charts require each other",
+			types.ErrorDetails{Cycle: cycle},
+		)
+
+		result.Summary.Cycles++
+		if slices.Contains(cycle, root) {
+			result.Success = false
+			result.Errors = append(result.Errors, validationError)
+		} else {
+			result.Summary.Warnings++
+			result.Warnings = append(result.Warnings, validationError)
+		}
+	}
+}
+
+// chartName returns the name of the checked chart, falling back to the base name of its
+// path when Chart.yaml cannot be read
+func (c *Checker) chartName(chartPath string) string {
+	if info, err := c.parser.GetChartInfo(chartPath); err == nil {
+		return info.Name
+	}
+	return filepath.Base(chartPath)
+}
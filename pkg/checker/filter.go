@@ -0,0 +1,91 @@
+package checker
+
+import (
+	"fmt"
+	"regexp"
+
+	"helm-depcheck/pkg/types"
+)
+
+// errNoTargetNamespace is returned for same-namespace dependencies when the namespace the
+// chart is installed into is not known
+var errNoTargetNamespace = fmt.Errorf("scope %s requires a target namespace (--namespace)", types.ScopeSameNamespace)
+
+// releaseFilter narrows the releases that count for a single dependency
+type releaseFilter struct {
+	namespace          string
+	namespacePattern   *regexp.Regexp
+	releaseName        string
+	releaseNamePattern *regexp.Regexp
+}
+
+// newReleaseFilter builds the release filter for the given dependency. Dependencies with
+// the same-namespace scope are restricted to the target namespace of the install.
+func newReleaseFilter(dep types.Dependency, targetNamespace string) (*releaseFilter, error) {
+	filter := &releaseFilter{
+		namespace:   dep.Namespace,
+		releaseName: dep.ReleaseName,
+	}
+
+	switch dep.EffectiveScope() {
+	case types.ScopeSameNamespace:
+		if targetNamespace == "" {
+			return nil, errNoTargetNamespace
+		}
+		filter.namespace = targetNamespace
+	case types.ScopeCluster, types.ScopeNamespaces:
+		// Namespace constraints, if any, come from the dependency itself
+	default:
+		return nil, fmt.Errorf("unknown scope '%s'", dep.Scope)
+	}
+
+	if dep.NamespacePattern != "" {
+		regex, err := regexp.Compile(dep.NamespacePattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid namespace pattern '%s': %v", dep.NamespacePattern, err)
+		}
+		filter.namespacePattern = regex
+	}
+
+	if dep.ReleaseNamePattern != "" {
+		regex, err := regexp.Compile(dep.ReleaseNamePattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid release name pattern '%s': %v", dep.ReleaseNamePattern, err)
+		}
+		filter.releaseNamePattern = regex
+	}
+
+	return filter, nil
+}
+
+// matches reports whether the release satisfies all constraints of the filter
+func (f *releaseFilter) matches(release types.Release) bool {
+	if f.namespace != "" && release.Namespace != f.namespace {
+		return false
+	}
+
+	if f.namespacePattern != nil && !f.namespacePattern.MatchString(release.Namespace) {
+		return false
+	}
+
+	if f.releaseName != "" && release.Name != f.releaseName {
+		return false
+	}
+
+	if f.releaseNamePattern != nil && !f.releaseNamePattern.MatchString(release.Name) {
+		return false
+	}
+
+	return true
+}
+
+// apply returns the releases matching the filter, preserving their order
+func (f *releaseFilter) apply(releases []types.Release) []types.Release {
+	var matching []types.Release
+	for _, release := range releases {
+		if f.matches(release) {
+			matching = append(matching, release)
+		}
+	}
+	return matching
+}
@@ -0,0 +1,97 @@
+package checker
+
+import (
+	"context"
+	"fmt"
+
+	"helm-depcheck/pkg/types"
+)
+
+// releaseInventory holds the deployed releases of a single check run indexed by chart name,
+// along with the namespace pattern they were listed with
+type releaseInventory struct {
+	byChartName      map[string][]types.Release
+	namespacePattern string
+}
+
+// newReleaseInventory indexes the given releases by chart name, preserving their order
+func newReleaseInventory(releases []types.Release, namespacePattern string) *releaseInventory {
+	inventory := &releaseInventory{
+		byChartName:      make(map[string][]types.Release),
+		namespacePattern: namespacePattern,
+	}
+
+	for _, release := range releases {
+		inventory.byChartName[release.Chart.Name] = append(inventory.byChartName[release.Chart.Name], release)
+	}
+
+	return inventory
+}
+
+// releasesForChart returns the releases deployed from the given chart
+func (i *releaseInventory) releasesForChart(chartName string) []types.Release {
+	return i.byChartName[chartName]
+}
+
+// isSearched reports whether releases of the given namespace were listed, i.e. whether
+// it matches the run's namespace pattern
+func (i *releaseInventory) isSearched(namespace string) bool {
+	matching, err := types.MatchNamespaces([]string{namespace}, i.namespacePattern)
+	return err == nil && len(matching) > 0
+}
+
+// releaseListing is the outcome of fetching the deployed releases of a run
+type releaseListing struct {
+	releases []types.Release
+	errors   []types.ValidationError
+	warnings []types.ValidationError
+	failed   bool
+}
+
+// runContext bounds all cluster queries of a run by the configured timeout
+func runContext(config types.Config) (context.Context, context.CancelFunc) {
+	if config.Timeout > 0 {
+		return context.WithTimeout(context.Background(), config.Timeout)
+	}
+	return context.WithCancel(context.Background())
+}
+
+// loadReleases fetches the deployed releases once per run and applies the assumed
+// overrides. A failed listing is reported as a single error and marks the listing failed.
+// Namespaces that could not be read may hide releases, so each of them is reported: as an
+// error in strict access mode and as a warning otherwise.
+func (c *Checker) loadReleases(ctx context.Context, config types.Config) releaseListing {
+	var listing releaseListing
+
+	releases, accessErrors, err := c.releaseSource.GetReleases(ctx, config.NamespacePattern)
+	if err != nil {
+		listing.failed = true
+		listing.errors = append(listing.errors, types.NewValidationError(
+			types.ErrorTypeHelmClientError,
+			"",
+			fmt.Sprintf("failed to get releases: %v", err),
+			types.ErrorDetails{SearchPattern: config.NamespacePattern},
+		))
+		return listing
+	}
+	listing.releases = applyAssumptions(releases, config.Assumptions)
+
+	for _, accessErr := range accessErrors {
+		validationError := types.NewValidationError(
+			types.ErrorTypeNamespaceUnreadable,
+			"",
+			accessErr.Message,
+			types.ErrorDetails{
+				Namespace:     accessErr.Namespace,
+				SearchPattern: config.NamespacePattern,
+			},
+		)
+		if config.StrictAccess {
+			listing.errors = append(listing.errors, validationError)
+		} else {
+			listing.warnings = append(listing.warnings, validationError)
+		}
+	}
+
+	return listing
+}
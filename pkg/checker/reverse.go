@@ -0,0 +1,181 @@
+package checker
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+
+	"helm-depcheck/pkg/types"
+)
+
+// CheckReverse evaluates a proposed upgrade or uninstall of the releases of a chart against
+// the dependencies declared by every deployed release. Each declaration naming the chart is
+// checked twice with the regular dependency logic: against the releases as deployed and
+// against the releases as they would be after the change. Declarations satisfied now but
+// not afterwards break.
+func (c *Checker) CheckReverse(config types.Config) (*types.ReverseResult, error) {
+	result := &types.ReverseResult{
+		Success:      true,
+		Chart:        config.ReverseChart,
+		Release:      config.ReverseRelease,
+		ToVersion:    config.ToVersion,
+		ToAppVersion: config.ToAppVersion,
+		Uninstall:    config.Uninstall,
+		Assumptions:  config.Assumptions,
+		Dependents:   []types.ReverseDependency{},
+		Errors:       []types.ValidationError{},
+		Warnings:     []types.ValidationError{},
+	}
+	ctx, cancel := runContext(config)
+	defer cancel()
+
+	listing := c.loadReleases(ctx, config)
+	result.Errors = append(result.Errors, listing.errors...)
+	result.Warnings = append(result.Warnings, listing.warnings...)
+	result.Summary.Warnings += len(listing.warnings)
+	if len(listing.errors) > 0 {
+		result.Success = false
+	}
+	if listing.failed {
+		return result, nil
+	}
+	releases := listing.releases
+	result.Summary.Releases = len(releases)
+
+	proposedReleases, changed := proposeChange(releases, config)
+	if len(changed) == 0 {
+		if config.ReverseRelease != "" {
+			return nil, fmt.Errorf("release %s of chart %s is not deployed", config.ReverseRelease, config.ReverseChart)
+		}
+		return nil, fmt.Errorf("no release of chart %s is deployed", config.ReverseChart)
+	}
+	result.Changed = changed
+
+	current := newReleaseInventory(releases, config.NamespacePattern)
+	proposed := newReleaseInventory(proposedReleases, config.NamespacePattern)
+
+	for _, release := range releases {
+		requiredBy := release.Label()
+
+		deps, err := c.parser.ParseReleaseDependencies(release)
+		if err != nil {
+			// Dependents with broken requirements can't be evaluated, but don't block the change
+			for _, validationErr := range types.ValidationErrors(nil).Append(err) {
+				validationErr.Details.RequiredBy = requiredBy
+				result.Summary.Warnings++
+				result.Warnings = append(result.Warnings, validationErr)
+			}
+			continue
+		}
+
+		for _, dep := range deps.Dependencies {
+			if !dependsOn(dep, config.ReverseChart) {
+				continue
+			}
+
+			dependent := types.ReverseDependency{
+				RequiredBy: requiredBy,
+				Current:    c.checkDependency(dep, current, release.Namespace),
+				Proposed:   c.checkDependency(dep, proposed, release.Namespace),
+			}
+			dependent.Breaks = dependent.Current.Status == types.StatusSatisfied &&
+				dependent.Proposed.Status != types.StatusSatisfied
+			result.Dependents = append(result.Dependents, dependent)
+			result.Summary.Dependents++
+
+			switch {
+			case dependent.Current.Status != types.StatusSatisfied:
+				// Already unmet, so the change doesn't make it worse
+				result.Summary.Unmet++
+			case !dependent.Breaks:
+				result.Summary.Unaffected++
+			default:
+				validationError := c.createValidationError(dependent.Proposed, config.NamespacePattern)
+				validationError.Details.RequiredBy = requiredBy
+				if dep.IsSoft() {
+					result.Summary.Warnings++
+					result.Warnings = append(result.Warnings, validationError)
+					continue
+				}
+
+				result.Summary.Broken++
+				result.Success = false
+				result.Errors = append(result.Errors, validationError)
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// proposeChange returns the releases as they would be after the proposed change, along
+// with the releases it changes. Upgraded releases keep their app version unless a new one
+// is given; uninstalled releases are left out.
+func proposeChange(releases []types.Release, config types.Config) ([]types.Release, []types.Release) {
+	var proposed, changed []types.Release
+	for _, release := range releases {
+		if release.Chart.Name != config.ReverseChart ||
+			(config.ReverseRelease != "" && release.Namespace+"/"+release.Name != config.ReverseRelease) {
+			proposed = append(proposed, release)
+			continue
+		}
+
+		changed = append(changed, release)
+		if config.Uninstall {
+			continue
+		}
+
+		release.Chart.Version = config.ToVersion
+		if config.ToAppVersion != "" {
+			release.Chart.AppVersion = config.ToAppVersion
+		}
+		proposed = append(proposed, release)
+	}
+
+	return proposed, changed
+}
+
+// dependsOn reports whether a dependency names the given chart, directly or as one of the
+// alternatives of an anyOf group
+func dependsOn(dep types.Dependency, chartName string) bool {
+	if !dep.IsGroup() {
+		return dep.Name == chartName
+	}
+
+	for _, alternative := range dep.AnyOf {
+		if alternative.Name == chartName {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateReverseConfig validates the configuration of a reverse check
+func (c *Checker) ValidateReverseConfig(config types.Config) error {
+	if config.ReverseChart == "" {
+		return fmt.Errorf("chart name is required")
+	}
+
+	if config.Uninstall == (config.ToVersion != "") {
+		return fmt.Errorf("exactly one of a target version or uninstall is required")
+	}
+
+	if config.ToVersion != "" {
+		if _, err := semver.NewVersion(config.ToVersion); err != nil {
+			return fmt.Errorf("invalid target version '%s': %v", config.ToVersion, err)
+		}
+	}
+
+	if config.ToAppVersion != "" && config.Uninstall {
+		return fmt.Errorf("a target app version can't be combined with uninstall")
+	}
+
+	if config.ReverseRelease != "" {
+		if parts := strings.Split(config.ReverseRelease, "/"); len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return fmt.Errorf("invalid release '%s': must be namespace/name", config.ReverseRelease)
+		}
+	}
+
+	return c.validateCommonConfig(config)
+}
@@ -0,0 +1,82 @@
+package checker
+
+import (
+	"helm-depcheck/pkg/graph"
+	"helm-depcheck/pkg/types"
+)
+
+// pendingRelease is a deployed release whose own requirements are still to be checked
+type pendingRelease struct {
+	release types.Release
+	depth   int
+}
+
+// checkTransitive follows the requirement graph beyond the chart's direct dependencies. The
+// releases satisfying a dependency are checked against the requirements stored with their
+// own charts, breadth first, until no new release is reached. Each release is visited once.
+func (c *Checker) checkTransitive(result *types.CheckResult, inventory *releaseInventory, dependencyGraph *graph.Graph, namespacePattern string) {
+	var queue []pendingRelease
+	visited := make(map[string]bool)
+
+	enqueue := func(depResult types.DependencyResult, depth int) {
+		if depResult.Status != types.StatusSatisfied || len(depResult.FoundReleases) == 0 {
+			return
+		}
+		release := depResult.FoundReleases[0]
+		key := release.Namespace + "/" + release.Name
+		if visited[key] {
+			return
+		}
+		visited[key] = true
+		queue = append(queue, pendingRelease{release: release, depth: depth})
+	}
+
+	// Requirements of the releases satisfying direct dependencies are the second level
+	for _, depResult := range result.Dependencies {
+		enqueue(depResult, 2)
+	}
+
+	for len(queue) > 0 {
+		pending := queue[0]
+		queue = queue[1:]
+		requiredBy := pending.release.Label()
+
+		deps, err := c.parser.ParseReleaseDependencies(pending.release)
+		if err != nil {
+			// A deployed chart with broken requirements doesn't fail the chart being checked
+			for _, validationErr := range types.ValidationErrors(nil).Append(err) {
+				validationErr.Details.RequiredBy = requiredBy
+				result.Warnings = append(result.Warnings, validationErr)
+				result.Summary.Warnings++
+			}
+			continue
+		}
+
+		for _, dep := range deps.Dependencies {
+			dependencyGraph.AddDependency(pending.release.Chart.Name, dep)
+
+			depResult := c.checkDependency(dep, inventory, pending.release.Namespace)
+			depResult.RequiredBy = requiredBy
+			depResult.Depth = pending.depth
+			result.Transitive = append(result.Transitive, depResult)
+			result.Summary.Transitive++
+
+			if depResult.Status == types.StatusSatisfied {
+				enqueue(depResult, pending.depth+1)
+				continue
+			}
+
+			validationError := c.createValidationError(depResult, namespacePattern)
+			validationError.Details.RequiredBy = requiredBy
+			if dep.IsSoft() {
+				result.Summary.Warnings++
+				result.Warnings = append(result.Warnings, validationError)
+				continue
+			}
+
+			result.Summary.BrokenLinks++
+			result.Success = false
+			result.Errors = append(result.Errors, validationError)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package graph
+
+import (
+	"strings"
+
+	"helm-depcheck/pkg/types"
+)
+
+// Edge is a requirement of one chart on another
+type Edge struct {
+	From string
+	To   string
+}
+
+// Graph is a directed graph of chart requirements. Nodes are chart names and edges point
+// from the requiring chart to the required one. Nodes and edges keep insertion order so
+// results are deterministic.
+type Graph struct {
+	nodes []string
+	known map[string]bool
+	edges map[string][]Edge
+}
+
+// New creates an empty Graph
+func New() *Graph {
+	return &Graph{
+		known: make(map[string]bool),
+		edges: make(map[string][]Edge),
+	}
+}
+
+// AddNode adds a chart to the graph if it is not part of it yet
+func (g *Graph) AddNode(name string) {
+	if g.known[name] {
+		return
+	}
+	g.known[name] = true
+	g.nodes = append(g.nodes, name)
+}
+
+// AddDependency adds the edges of a dependency declared by the given chart. An anyOf group
+// adds an edge to each of its alternatives. Repeated edges between the same charts are
+// only added once.
+func (g *Graph) AddDependency(from string, dep types.Dependency) {
+	if dep.IsGroup() {
+		for _, alternative := range dep.AnyOf {
+			g.AddDependency(from, alternative)
+		}
+		return
+	}
+
+	g.AddNode(from)
+	g.AddNode(dep.Name)
+	for _, edge := range g.edges[from] {
+		if edge.To == dep.Name {
+			return
+		}
+	}
+	g.edges[from] = append(g.edges[from], Edge{From: from, To: dep.Name})
+}
+
+// Edges returns all edges of the graph, grouped by requiring chart
+func (g *Graph) Edges() []Edge {
+	var edges []Edge
+	for _, node := range g.nodes {
+		edges = append(edges, g.edges[node]...)
+	}
+	return edges
+}
+
+// Cycles returns the requirement cycles of the graph as paths that start and end with the
+// same chart, e.g. [a b c a]. Every cycle found by a depth-first search is reported once,
+// so each strongly connected component yields at least one cycle.
+func (g *Graph) Cycles() [][]string {
+	const (
+		unvisited = iota
+		inProgress
+		done
+	)
+
+	state := make(map[string]int)
+	seen := make(map[string]bool)
+	var stack []string
+	var cycles [][]string
+
+	var visit func(node string)
+	visit = func(node string) {
+		state[node] = inProgress
+		stack = append(stack, node)
+
+		for _, edge := range g.edges[node] {
+			switch state[edge.To] {
+			case unvisited:
+				visit(edge.To)
+			case inProgress:
+				// The target is on the current path, so the path from it back here is a cycle
+				start := len(stack) - 1
+				for stack[start] != edge.To {
+					start--
+				}
+				cycle := append(append([]string(nil), stack[start:]...), edge.To)
+
+				key := cycleKey(cycle)
+				if !seen[key] {
+					seen[key] = true
+					cycles = append(cycles, cycle)
+				}
+			}
+		}
+
+		stack = stack[:len(stack)-1]
+		state[node] = done
+	}
+
+	for _, node := range g.nodes {
+		if state[node] == unvisited {
+			visit(node)
+		}
+	}
+
+	return cycles
+}
+
+// cycleKey identifies a cycle regardless of the chart it starts with, by rotating it to
+// start with its smallest chart name
+func cycleKey(cycle []string) string {
+	path := cycle[:len(cycle)-1]
+	smallest := 0
+	for i, node := range path {
+		if node < path[smallest] {
+			smallest = i
+		}
+	}
+
+	rotated := append(append([]string(nil), path[smallest:]...), path[:smallest]...)
+	return strings.Join(rotated, "\x00")
+}
@@ -0,0 +1,58 @@
+package graph
+
+import (
+	"reflect"
+	"testing"
+
+	"helm-depcheck/pkg/types"
+)
+
+func TestCycles(t *testing.T) {
+	tests := []struct {
+		name  string
+		edges [][2]string
+		want  [][]string
+	}{
+		{
+			name:  "acyclic",
+			edges: [][2]string{{"app", "redis"}, {"app", "postgresql"}, {"postgresql", "backup"}},
+		},
+		{
+			name:  "self requirement",
+			edges: [][2]string{{"app", "app"}},
+			want:  [][]string{{"app", "app"}},
+		},
+		{
+			name:  "cycle reported once",
+			edges: [][2]string{{"app", "api"}, {"api", "worker"}, {"worker", "api"}, {"worker", "app"}},
+			want:  [][]string{{"api", "worker", "api"}, {"app", "api", "worker", "app"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New()
+			for _, edge := range tt.edges {
+				g.AddDependency(edge[0], types.Dependency{Name: edge[1]})
+			}
+
+			if got := g.Cycles(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Cycles() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddDependencyGroup(t *testing.T) {
+	g := New()
+	g.AddDependency("app", types.Dependency{AnyOf: []types.Dependency{{Name: "redis"}, {Name: "valkey"}}})
+	g.AddDependency("app", types.Dependency{Name: "redis"})
+
+	var targets []string
+	for _, edge := range g.Edges() {
+		targets = append(targets, edge.To)
+	}
+	if want := []string{"redis", "valkey"}; !reflect.DeepEqual(targets, want) {
+		t.Errorf("edges point to %v, want %v", targets, want)
+	}
+}
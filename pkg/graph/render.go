@@ -0,0 +1,287 @@
+package graph
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"helm-depcheck/pkg/types"
+)
+
+// Formats a Diagram can be rendered in
+const (
+	FormatDOT     = "dot"
+	FormatMermaid = "mermaid"
+	FormatJSON    = "json"
+)
+
+// Kinds of diagram nodes
+const (
+	NodeChart   = "chart"
+	NodeRelease = "release"
+	NodeGroup   = "group"
+	NodeMissing = "missing"
+)
+
+// Kinds of diagram edges
+const (
+	EdgeRequires  = "requires"
+	EdgeConflicts = "conflicts"
+)
+
+// DiagramNode is the checked chart, a deployed release, an anyOf group or a required chart
+// that has no matching release
+type DiagramNode struct {
+	ID    string `json:"id"`
+	Label string `json:"label"`
+	Kind  string `json:"kind"`
+}
+
+// DiagramEdge links a node to a release or chart it requires or conflicts with
+type DiagramEdge struct {
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Label  string `json:"label,omitempty"`
+	Kind   string `json:"kind"`
+	Status string `json:"status"`
+	Color  string `json:"color"`
+}
+
+// Diagram is the dependency graph of a check result: the checked chart, its declared
+// dependencies and the deployed releases matching them
+type Diagram struct {
+	Nodes []DiagramNode `json:"nodes"`
+	Edges []DiagramEdge `json:"edges"`
+
+	chart    string
+	index    map[string]int
+	releases map[string]string
+	linked   map[string]bool
+}
+
+// NewDiagram creates the diagram of a check result. Requirements found in transitive mode
+// start at the release that declared them.
+func NewDiagram(result *types.CheckResult) *Diagram {
+	d := &Diagram{
+		Nodes:    []DiagramNode{},
+		Edges:    []DiagramEdge{},
+		chart:    result.Chart,
+		index:    make(map[string]int),
+		releases: make(map[string]string),
+		linked:   make(map[string]bool),
+	}
+
+	root := "chart:" + result.Chart
+	d.addNode(root, result.Chart, NodeChart)
+
+	for _, depResult := range result.Dependencies {
+		d.addResult(root, depResult, EdgeRequires)
+	}
+	for _, conflictResult := range result.Conflicts {
+		// Only conflicting releases that are deployed are part of the graph
+		if len(conflictResult.FoundReleases) > 0 {
+			d.addResult(root, conflictResult, EdgeConflicts)
+		}
+	}
+	for _, depResult := range result.Transitive {
+		from, ok := d.releases[depResult.RequiredBy]
+		if !ok {
+			continue
+		}
+		d.addResult(from, depResult, EdgeRequires)
+	}
+
+	return d
+}
+
+// addResult adds the edges of a dependency result, pointing to each matching release, to
+// the required chart when none matches, or through a group node to each alternative
+func (d *Diagram) addResult(from string, depResult types.DependencyResult, kind string) {
+	label := edgeLabel(depResult, kind)
+
+	if len(depResult.Alternatives) > 0 {
+		group := "group:" + from + ":" + depResult.Name
+		d.addNode(group, depResult.Name, NodeGroup)
+		d.addEdge(from, group, label, kind, depResult.Status)
+		for _, alternative := range depResult.Alternatives {
+			d.addResult(group, alternative, kind)
+		}
+		return
+	}
+
+	if len(depResult.FoundReleases) == 0 {
+		// A release requiring the checked chart closes a cycle through it
+		if depResult.Name == d.chart {
+			d.addEdge(from, "chart:"+d.chart, label, kind, depResult.Status)
+			return
+		}
+		missing := "missing:" + depResult.Name
+		d.addNode(missing, depResult.Name+" (not deployed)", NodeMissing)
+		d.addEdge(from, missing, label, kind, depResult.Status)
+		return
+	}
+
+	for _, release := range depResult.FoundReleases {
+		id := "release:" + release.Namespace + "/" + release.Name
+		releaseLabel := fmt.Sprintf("%s/%s@%s", release.Namespace, release.Name, release.Chart.Version)
+		if release.Assumed {
+			releaseLabel += " (assumed)"
+		}
+		d.addNode(id, releaseLabel, NodeRelease)
+		d.releases[release.Label()] = id
+		d.addEdge(from, id, label, kind, depResult.Status)
+	}
+}
+
+// addNode adds a node if it is not part of the diagram yet
+func (d *Diagram) addNode(id, label, kind string) {
+	if _, ok := d.index[id]; ok {
+		return
+	}
+	d.index[id] = len(d.Nodes)
+	d.Nodes = append(d.Nodes, DiagramNode{ID: id, Label: label, Kind: kind})
+}
+
+// addEdge adds an edge if the nodes are not linked yet
+func (d *Diagram) addEdge(from, to, label, kind, status string) {
+	key := from + "\x00" + to
+	if d.linked[key] {
+		return
+	}
+	d.linked[key] = true
+	d.Edges = append(d.Edges, DiagramEdge{
+		From:   from,
+		To:     to,
+		Label:  label,
+		Kind:   kind,
+		Status: status,
+		Color:  StatusColor(status),
+	})
+}
+
+// edgeLabel describes the constraints of a dependency result, e.g. "^17.0.0 app >=7.0"
+func edgeLabel(depResult types.DependencyResult, kind string) string {
+	var parts []string
+	if kind == EdgeConflicts {
+		parts = append(parts, "conflicts")
+	}
+	if depResult.RequiredVersion != "" {
+		parts = append(parts, depResult.RequiredVersion)
+	}
+	if depResult.RequiredAppVersion != "" {
+		parts = append(parts, "app "+depResult.RequiredAppVersion)
+	}
+	return strings.Join(parts, " ")
+}
+
+// StatusColor returns the color edges with the given dependency status are drawn in
+func StatusColor(status string) string {
+	switch status {
+	case types.StatusSatisfied, types.StatusNoConflict:
+		return "#2e7d32" // green
+	case types.StatusVersionMismatch, types.StatusAppVersionMismatch, types.StatusInvalidAppVersion,
+		types.StatusMultipleFound:
+		return "#ef6c00" // orange
+	case types.StatusNotFound, types.StatusNoAlternative, types.StatusConflict, types.StatusContradictory,
+		types.StatusNoTargetNamespace:
+		return "#c62828" // red
+	default:
+		return "#757575" // gray
+	}
+}
+
+// Render renders the diagram in the given format
+func (d *Diagram) Render(format string) ([]byte, error) {
+	switch format {
+	case FormatDOT:
+		return []byte(d.DOT()), nil
+	case FormatMermaid:
+		return []byte(d.Mermaid()), nil
+	case FormatJSON:
+		data, err := json.MarshalIndent(d, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return append(data, '\n'), nil
+	default:
+		return nil, fmt.Errorf("unsupported graph format: %s (use %s, %s or %s)", format, FormatDOT, FormatMermaid, FormatJSON)
+	}
+}
+
+// DOT renders the diagram in the Graphviz DOT language
+func (d *Diagram) DOT() string {
+	var b strings.Builder
+	b.WriteString("digraph dependencies {\n")
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=box];\n")
+
+	for _, node := range d.Nodes {
+		attributes := "label=" + strconv.Quote(node.Label)
+		switch node.Kind {
+		case NodeChart:
+			attributes += ", style=bold"
+		case NodeGroup:
+			attributes += ", shape=diamond"
+		case NodeMissing:
+			attributes += ", style=dashed"
+		}
+		fmt.Fprintf(&b, "  %s [%s];\n", strconv.Quote(node.ID), attributes)
+	}
+
+	for _, edge := range d.Edges {
+		attributes := fmt.Sprintf("color=%q, fontcolor=%q", edge.Color, edge.Color)
+		if edge.Label != "" {
+			attributes = "label=" + strconv.Quote(edge.Label) + ", " + attributes
+		}
+		if edge.Kind == EdgeConflicts {
+			attributes += ", style=dashed"
+		}
+		fmt.Fprintf(&b, "  %s -> %s [%s];\n", strconv.Quote(edge.From), strconv.Quote(edge.To), attributes)
+	}
+
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// Mermaid renders the diagram as a Mermaid flowchart. Node IDs are replaced by short
+// identifiers since Mermaid does not allow arbitrary characters in them.
+func (d *Diagram) Mermaid() string {
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+
+	for i, node := range d.Nodes {
+		label := mermaidText(node.Label)
+		switch node.Kind {
+		case NodeGroup:
+			fmt.Fprintf(&b, "  n%d{\"%s\"}\n", i, label)
+		case NodeMissing:
+			fmt.Fprintf(&b, "  n%d([\"%s\"])\n", i, label)
+		default:
+			fmt.Fprintf(&b, "  n%d[\"%s\"]\n", i, label)
+		}
+	}
+
+	for _, edge := range d.Edges {
+		arrow := "-->"
+		if edge.Kind == EdgeConflicts {
+			arrow = "-.->"
+		}
+		if edge.Label != "" {
+			arrow += "|\"" + mermaidText(edge.Label) + "\"|"
+		}
+		fmt.Fprintf(&b, "  n%d %s n%d\n", d.index[edge.From], arrow, d.index[edge.To])
+	}
+
+	// Links are styled by their position in the order they were declared
+	for i, edge := range d.Edges {
+		fmt.Fprintf(&b, "  linkStyle %d stroke:%s,color:%s\n", i, edge.Color, edge.Color)
+	}
+
+	return b.String()
+}
+
+// mermaidText escapes the characters that end a quoted Mermaid label
+func mermaidText(text string) string {
+	return strings.ReplaceAll(text, `"`, "#quot;")
+}
@@ -3,35 +3,86 @@ package helm
 import (
 	"context"
 	"fmt"
-	"regexp"
+	"os"
+	"sort"
+	"sync"
+	"sync/atomic"
 
 	"helm.sh/helm/v3/pkg/action"
 	"helm.sh/helm/v3/pkg/cli"
 	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/storage"
+	"helm.sh/helm/v3/pkg/storage/driver"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 
+	"helm-depcheck/pkg/parser"
 	"helm-depcheck/pkg/types"
 )
 
+// DefaultConcurrency is the default number of namespaces listed in parallel
+const DefaultConcurrency = 10
+
+// DefaultStorageDriver is the storage driver used when neither the options nor HELM_DRIVER set one
+const DefaultStorageDriver = "secret"
+
+// sqlConnectionStringEnv is the environment variable Helm reads the SQL driver connection string from,
+// used when the options don't set one
+const sqlConnectionStringEnv = "HELM_DRIVER_SQL_CONNECTION_STRING"
+
+// Options holds configuration for the Helm client
+type Options struct {
+	KubeConfig          string
+	Concurrency         int
+	StorageDriver       string
+	SQLConnectionString string
+}
+
 // Client wraps Helm client functionality
 type Client struct {
-	settings   *cli.EnvSettings
-	kubeClient kubernetes.Interface
+	settings      *cli.EnvSettings
+	kubeClient    kubernetes.Interface
+	concurrency   int
+	storageDriver string
+
+	// sqlConnectionString is the connection string of the sql storage driver
+	sqlConnectionString string
+
+	// clusterWideForbidden is set once RBAC denied a cluster-wide release listing
+	clusterWideForbidden atomic.Bool
 }
 
 // NewClient creates a new Helm client instance
-func NewClient(kubeConfig string) (*Client, error) {
+func NewClient(options Options) (*Client, error) {
 	settings := cli.New()
 
-	if kubeConfig != "" {
-		settings.KubeConfig = kubeConfig
+	if options.KubeConfig != "" {
+		settings.KubeConfig = options.KubeConfig
+	}
+
+	concurrency := options.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+
+	storageDriver, err := resolveStorageDriver(options.StorageDriver)
+	if err != nil {
+		return nil, err
+	}
+
+	sqlConnectionString := options.SQLConnectionString
+	if sqlConnectionString == "" {
+		sqlConnectionString = os.Getenv(sqlConnectionStringEnv)
+	}
+	if storageDriver == "sql" && sqlConnectionString == "" {
+		return nil, fmt.Errorf("the sql storage driver requires a connection string (--sql-connection-string or %s)", sqlConnectionStringEnv)
 	}
 
 	// Create Kubernetes client
-	config, err := buildKubeConfig(kubeConfig)
+	config, err := buildKubeConfig(options.KubeConfig)
 	if err != nil {
 		return nil, fmt.Errorf("failed to build kube config: %v", err)
 	}
@@ -42,79 +93,199 @@ func NewClient(kubeConfig string) (*Client, error) {
 	}
 
 	return &Client{
-		settings:   settings,
-		kubeClient: kubeClient,
+		settings:            settings,
+		kubeClient:          kubeClient,
+		concurrency:         concurrency,
+		storageDriver:       storageDriver,
+		sqlConnectionString: sqlConnectionString,
 	}, nil
 }
 
-// GetReleases retrieves all deployed Helm releases matching the namespace pattern
-func (c *Client) GetReleases(namespacePattern string) ([]types.Release, error) {
-	namespaces, err := c.getMatchingNamespaces(namespacePattern)
+// resolveStorageDriver returns the storage driver to use, falling back to HELM_DRIVER and
+// then to the default secret driver
+func resolveStorageDriver(driver string) (string, error) {
+	if driver == "" {
+		driver = os.Getenv("HELM_DRIVER")
+	}
+
+	switch driver {
+	case "":
+		return DefaultStorageDriver, nil
+	case "secret", "secrets", "configmap", "configmaps", "memory", "sql":
+		return driver, nil
+	default:
+		return "", fmt.Errorf("unknown storage driver '%s': must be one of secret, configmap, memory, sql", driver)
+	}
+}
+
+// GetReleases retrieves all deployed Helm releases matching the namespace pattern. Namespaces
+// whose releases could not be listed are returned as access errors alongside the releases.
+func (c *Client) GetReleases(ctx context.Context, namespacePattern string) ([]types.Release, []types.NamespaceAccessError, error) {
+	namespaces, err := c.getMatchingNamespaces(ctx, namespacePattern)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get matching namespaces: %v", err)
+		return nil, nil, fmt.Errorf("failed to get matching namespaces: %v", err)
 	}
 
-	var allReleases []types.Release
+	// Prefer a single cluster-wide query and fall back to per-namespace
+	// listing only when RBAC forbids reading releases across namespaces. A
+	// cluster-wide query abandoned at the deadline leaks its goroutine until
+	// the API server answers and is not retried per namespace.
+	if !c.clusterWideForbidden.Load() {
+		releases, err := c.getReleasesInAllNamespaces(ctx, namespaces)
+		if err == nil {
+			return releases, nil, nil
+		}
+		if !apierrors.IsForbidden(err) {
+			return nil, nil, err
+		}
+		c.clusterWideForbidden.Store(true)
+	}
+
+	return c.getReleasesInNamespaces(ctx, namespaces)
+}
+
+// getReleasesInAllNamespaces lists deployed releases of all namespaces in one query and keeps
+// those in the given namespaces, ordered the same way as per-namespace listing would.
+func (c *Client) getReleasesInAllNamespaces(ctx context.Context, namespaces []string) ([]types.Release, error) {
+	actionConfig, err := c.newActionConfig("")
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize cluster-wide action config: %w", err)
+	}
+
+	listAction := action.NewList(actionConfig)
+	listAction.Deployed = true // Only get deployed releases
+	listAction.AllNamespaces = true
+
+	// Listing cannot be interrupted, so stop waiting once the deadline passes
+	type listResult struct {
+		releases []*release.Release
+		err      error
+	}
+	listed := make(chan listResult, 1)
+	go func() {
+		releases, err := listAction.Run()
+		listed <- listResult{releases: releases, err: err}
+	}()
+
+	var releases []*release.Release
+	select {
+	case result := <-listed:
+		if result.err != nil {
+			return nil, fmt.Errorf("failed to list releases in all namespaces: %w", result.err)
+		}
+		releases = result.releases
+	case <-ctx.Done():
+		// The listing goroutine leaks until the API server answers; drain its result so
+		// the listed releases are dropped as soon as it does
+		go func() { <-listed }()
+		return nil, fmt.Errorf("release listing did not finish: %v", ctx.Err())
+	}
+
+	namespaceOrder := make(map[string]int, len(namespaces))
+	for i, namespace := range namespaces {
+		namespaceOrder[namespace] = i
+	}
+
+	var result []types.Release
+	for _, rel := range releases {
+		if _, ok := namespaceOrder[rel.Namespace]; ok && rel.Info.Status == release.StatusDeployed {
+			result = append(result, convertRelease(rel))
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return namespaceOrder[result[i].Namespace] < namespaceOrder[result[j].Namespace]
+	})
+
+	return result, nil
+}
 
-	for _, namespace := range namespaces {
-		releases, err := c.getReleasesInNamespace(namespace)
-		if err != nil {
-			// Log error but continue with other namespaces
+// getReleasesInNamespaces lists releases of the given namespaces in parallel using a bounded
+// worker pool. Releases are returned in namespace order regardless of completion order.
+func (c *Client) getReleasesInNamespaces(ctx context.Context, namespaces []string) ([]types.Release, []types.NamespaceAccessError, error) {
+	// Results are stored by namespace index to keep the output deterministic
+	results := make([][]types.Release, len(namespaces))
+	listErrors := make([]error, len(namespaces))
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	for w := 0; w < min(c.concurrency, len(namespaces)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i], listErrors[i] = c.getReleasesInNamespace(namespaces[i])
+			}
+		}()
+	}
+
+dispatch:
+	for i := range namespaces {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(jobs)
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	// Listing a namespace cannot be interrupted, so stop waiting once the deadline passes
+	select {
+	case <-done:
+	case <-ctx.Done():
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, nil, fmt.Errorf("release listing did not finish: %v", err)
+	}
+
+	// Unreadable namespaces are reported instead of being mistaken for empty ones
+	var allReleases []types.Release
+	var accessErrors []types.NamespaceAccessError
+	for i, releases := range results {
+		if listErrors[i] != nil {
+			accessErrors = append(accessErrors, types.NamespaceAccessError{
+				Namespace: namespaces[i],
+				Message:   listErrors[i].Error(),
+			})
 			continue
 		}
 		allReleases = append(allReleases, releases...)
 	}
 
-	return allReleases, nil
+	return allReleases, accessErrors, nil
 }
 
 // GetMatchingNamespaces returns namespaces that match the given pattern (public method)
-func (c *Client) GetMatchingNamespaces(pattern string) ([]string, error) {
-	return c.getMatchingNamespaces(pattern)
+func (c *Client) GetMatchingNamespaces(ctx context.Context, pattern string) ([]string, error) {
+	return c.getMatchingNamespaces(ctx, pattern)
 }
 
 // getMatchingNamespaces returns namespaces that match the given pattern
-func (c *Client) getMatchingNamespaces(pattern string) ([]string, error) {
+func (c *Client) getMatchingNamespaces(ctx context.Context, pattern string) ([]string, error) {
 	// Get all namespaces
-	namespaceList, err := c.kubeClient.CoreV1().Namespaces().List(context.TODO(), metav1.ListOptions{})
+	namespaceList, err := c.kubeClient.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
 	if err != nil {
 		return nil, fmt.Errorf("failed to list namespaces: %v", err)
 	}
 
-	var matchingNamespaces []string
-
-	// Handle empty pattern - return all non-system namespaces
-	if pattern == "" {
-		for _, ns := range namespaceList.Items {
-			namespace := ns.Name
-			if !types.IsSystemNamespace(namespace) {
-				matchingNamespaces = append(matchingNamespaces, namespace)
-			}
-		}
-		return matchingNamespaces, nil
-	}
-
-	// Compile regex pattern for custom patterns
-	regex, err := regexp.Compile(pattern)
-	if err != nil {
-		return nil, fmt.Errorf("invalid namespace pattern '%s': %v", pattern, err)
-	}
-
+	namespaces := make([]string, 0, len(namespaceList.Items))
 	for _, ns := range namespaceList.Items {
-		namespace := ns.Name
-		if regex.MatchString(namespace) {
-			matchingNamespaces = append(matchingNamespaces, namespace)
-		}
+		namespaces = append(namespaces, ns.Name)
 	}
 
-	return matchingNamespaces, nil
+	return types.MatchNamespaces(namespaces, pattern)
 }
 
 // getReleasesInNamespace retrieves all deployed releases in a specific namespace
 func (c *Client) getReleasesInNamespace(namespace string) ([]types.Release, error) {
-	actionConfig := new(action.Configuration)
-
-	if err := actionConfig.Init(c.settings.RESTClientGetter(), namespace, "secret", func(format string, v ...interface{}) {}); err != nil {
+	actionConfig, err := c.newActionConfig(namespace)
+	if err != nil {
 		return nil, fmt.Errorf("failed to initialize action config for namespace %s: %v", namespace, err)
 	}
 
@@ -130,38 +301,65 @@ func (c *Client) getReleasesInNamespace(namespace string) ([]types.Release, erro
 	var result []types.Release
 	for _, rel := range releases {
 		if rel.Info.Status == release.StatusDeployed {
-			result = append(result, types.Release{
-				Name:      rel.Name,
-				Namespace: rel.Namespace,
-				Chart: types.ChartInfo{
-					Name:    rel.Chart.Metadata.Name,
-					Version: rel.Chart.Metadata.Version,
-				},
-				Status:  rel.Info.Status.String(),
-				Version: rel.Version,
-				Updated: rel.Info.LastDeployed.Time,
-			})
+			result = append(result, convertRelease(rel))
 		}
 	}
 
 	return result, nil
 }
 
-// FindReleasesByChartName finds all releases for a specific chart name across namespaces
-func (c *Client) FindReleasesByChartName(chartName, namespacePattern string) ([]types.Release, error) {
-	allReleases, err := c.GetReleases(namespacePattern)
-	if err != nil {
+// newActionConfig creates the action configuration reading releases of the given namespace,
+// or of all namespaces when it is empty
+func (c *Client) newActionConfig(namespace string) (*action.Configuration, error) {
+	actionConfig := new(action.Configuration)
+	log := func(format string, v ...interface{}) {}
+
+	if c.storageDriver != "sql" {
+		if err := actionConfig.Init(c.settings.RESTClientGetter(), namespace, c.storageDriver, log); err != nil {
+			return nil, err
+		}
+		return actionConfig, nil
+	}
+
+	// Helm only reads the SQL connection string from the environment, so the configuration is
+	// initialized with the memory driver and its storage replaced by an SQL driver created here
+	if err := actionConfig.Init(c.settings.RESTClientGetter(), namespace, "memory", log); err != nil {
 		return nil, err
 	}
+	sqlDriver, err := driver.NewSQL(c.sqlConnectionString, log, namespace)
+	if err != nil {
+		return nil, fmt.Errorf("unable to instantiate SQL driver: %w", err)
+	}
+	actionConfig.Releases = storage.Init(sqlDriver)
 
-	var matchingReleases []types.Release
-	for _, release := range allReleases {
-		if release.Chart.Name == chartName {
-			matchingReleases = append(matchingReleases, release)
+	return actionConfig, nil
+}
+
+// convertRelease converts a Helm release into a types.Release, keeping the dependency
+// requirements stored with its chart
+func convertRelease(rel *release.Release) types.Release {
+	chart := types.ChartInfo{
+		Name:                   rel.Chart.Metadata.Name,
+		Version:                rel.Chart.Metadata.Version,
+		AppVersion:             rel.Chart.Metadata.AppVersion,
+		DependenciesAnnotation: rel.Chart.Metadata.Annotations[parser.DependenciesAnnotation],
+	}
+
+	for _, file := range rel.Chart.Files {
+		if file.Name == "dependencies.yaml" {
+			chart.DependenciesFile = file.Data
+			break
 		}
 	}
 
-	return matchingReleases, nil
+	return types.Release{
+		Name:      rel.Name,
+		Namespace: rel.Namespace,
+		Chart:     chart,
+		Status:    rel.Info.Status.String(),
+		Version:   rel.Version,
+		Updated:   rel.Info.LastDeployed.Time,
+	}
 }
 
 // ValidateConnection validates the connection to Kubernetes and Helm
@@ -0,0 +1,181 @@
+package fake
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"helm-depcheck/pkg/parser"
+	"helm-depcheck/pkg/types"
+)
+
+// Fixture represents the structure of a releases fixture file
+type Fixture struct {
+	Namespaces           []string         `yaml:"namespaces"`
+	UnreadableNamespaces []string         `yaml:"unreadableNamespaces"`
+	Releases             []FixtureRelease `yaml:"releases"`
+}
+
+// FixtureRelease represents a single deployed release in a fixture file
+type FixtureRelease struct {
+	Name      string       `yaml:"name"`
+	Namespace string       `yaml:"namespace"`
+	Chart     FixtureChart `yaml:"chart"`
+	Status    string       `yaml:"status"`
+	Revision  int          `yaml:"revision"`
+	Updated   time.Time    `yaml:"updated"`
+}
+
+// FixtureChart contains the chart information of a fixture release. The dependencies
+// file holds the contents of the chart's dependencies.yaml.
+type FixtureChart struct {
+	Name             string            `yaml:"name"`
+	Version          string            `yaml:"version"`
+	AppVersion       string            `yaml:"appVersion"`
+	Annotations      map[string]string `yaml:"annotations"`
+	DependenciesFile string            `yaml:"dependenciesFile"`
+}
+
+// ReleaseSource is an in-memory release source backed by static data
+type ReleaseSource struct {
+	namespaces []string
+	releases   []types.Release
+	unreadable map[string]bool
+}
+
+// NewReleaseSource creates a new in-memory ReleaseSource. Namespaces of the
+// given releases are added to the namespace list if missing.
+func NewReleaseSource(namespaces []string, releases []types.Release) *ReleaseSource {
+	seen := make(map[string]bool)
+	var allNamespaces []string
+	for _, namespace := range namespaces {
+		if !seen[namespace] {
+			seen[namespace] = true
+			allNamespaces = append(allNamespaces, namespace)
+		}
+	}
+	for _, release := range releases {
+		if !seen[release.Namespace] {
+			seen[release.Namespace] = true
+			allNamespaces = append(allNamespaces, release.Namespace)
+		}
+	}
+	sort.Strings(allNamespaces)
+
+	return &ReleaseSource{
+		namespaces: allNamespaces,
+		releases:   releases,
+		unreadable: make(map[string]bool),
+	}
+}
+
+// SetUnreadable marks a namespace as unreadable. Its releases are hidden and
+// reported as a namespace access error, as if RBAC denied listing them.
+func (s *ReleaseSource) SetUnreadable(namespace string) {
+	if !s.unreadable[namespace] && !slices.Contains(s.namespaces, namespace) {
+		s.namespaces = append(s.namespaces, namespace)
+		sort.Strings(s.namespaces)
+	}
+	s.unreadable[namespace] = true
+}
+
+// LoadFixture creates a ReleaseSource from a YAML fixture file
+func LoadFixture(path string) (*ReleaseSource, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read releases fixture: %v", err)
+	}
+
+	// Reject unknown fields so typos don't silently drop data from the fixture
+	var fixture Fixture
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&fixture); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to parse releases fixture %s: %v", path, err)
+	}
+
+	releases := make([]types.Release, 0, len(fixture.Releases))
+	for i, rel := range fixture.Releases {
+		if rel.Name == "" || rel.Namespace == "" || rel.Chart.Name == "" {
+			return nil, fmt.Errorf("invalid release #%d in fixture %s: name, namespace and chart.name are required", i+1, path)
+		}
+
+		status := rel.Status
+		if status == "" {
+			status = "deployed"
+		}
+		revision := rel.Revision
+		if revision == 0 {
+			revision = 1
+		}
+
+		releases = append(releases, types.Release{
+			Name:      rel.Name,
+			Namespace: rel.Namespace,
+			Chart: types.ChartInfo{
+				Name:                   rel.Chart.Name,
+				Version:                rel.Chart.Version,
+				AppVersion:             rel.Chart.AppVersion,
+				DependenciesFile:       []byte(rel.Chart.DependenciesFile),
+				DependenciesAnnotation: rel.Chart.Annotations[parser.DependenciesAnnotation],
+			},
+			Status:  status,
+			Version: revision,
+			Updated: rel.Updated,
+		})
+	}
+
+	source := NewReleaseSource(fixture.Namespaces, releases)
+	for _, namespace := range fixture.UnreadableNamespaces {
+		source.SetUnreadable(namespace)
+	}
+
+	return source, nil
+}
+
+// GetMatchingNamespaces returns namespaces that match the given pattern
+func (s *ReleaseSource) GetMatchingNamespaces(ctx context.Context, pattern string) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return types.MatchNamespaces(s.namespaces, pattern)
+}
+
+// GetReleases retrieves all deployed releases matching the namespace pattern
+func (s *ReleaseSource) GetReleases(ctx context.Context, namespacePattern string) ([]types.Release, []types.NamespaceAccessError, error) {
+	namespaces, err := s.GetMatchingNamespaces(ctx, namespacePattern)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get matching namespaces: %v", err)
+	}
+
+	matched := make(map[string]bool, len(namespaces))
+	var accessErrors []types.NamespaceAccessError
+	for _, namespace := range namespaces {
+		if s.unreadable[namespace] {
+			accessErrors = append(accessErrors, types.NamespaceAccessError{
+				Namespace: namespace,
+				Message:   "forbidden by releases fixture",
+			})
+			continue
+		}
+		matched[namespace] = true
+	}
+
+	var result []types.Release
+	for _, release := range s.releases {
+		// Only deployed releases are visible, as with the Helm client
+		if matched[release.Namespace] && release.Status == "deployed" {
+			result = append(result, release)
+		}
+	}
+
+	return result, accessErrors, nil
+}
@@ -0,0 +1,148 @@
+package fake
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"helm-depcheck/pkg/types"
+)
+
+// writeFixture writes a releases fixture file with the given contents
+func writeFixture(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "releases.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadFixture(t *testing.T) {
+	path := writeFixture(t, `namespaces: [empty]
+unreadableNamespaces: [restricted]
+releases:
+  - name: redis
+    namespace: shared
+    chart:
+      name: redis
+      version: 17.3.0
+      appVersion: 7.0.5
+      dependenciesFile: |
+        dependencies:
+          - name: postgresql
+            version: ^15.0.0
+  - name: db
+    namespace: data
+    chart:
+      name: postgresql
+      version: 15.4.0
+    status: deployed
+    revision: 3
+  - name: broken
+    namespace: data
+    chart:
+      name: postgresql
+      version: 15.5.0
+    status: failed
+`)
+
+	source, err := LoadFixture(path)
+	if err != nil {
+		t.Fatalf("LoadFixture() error = %v", err)
+	}
+
+	namespaces, err := source.GetMatchingNamespaces(context.Background(), "")
+	if err != nil {
+		t.Fatalf("GetMatchingNamespaces() error = %v", err)
+	}
+	if got, want := strings.Join(namespaces, ","), "data,empty,restricted,shared"; got != want {
+		t.Errorf("namespaces = %s, want %s", got, want)
+	}
+
+	releases, accessErrors, err := source.GetReleases(context.Background(), "")
+	if err != nil {
+		t.Fatalf("GetReleases() error = %v", err)
+	}
+
+	if len(accessErrors) != 1 || accessErrors[0].Namespace != "restricted" {
+		t.Errorf("access errors = %+v, want only namespace restricted", accessErrors)
+	}
+
+	byName := make(map[string]types.Release)
+	for _, release := range releases {
+		byName[release.Name] = release
+	}
+	if len(releases) != 2 || byName["broken"].Name != "" {
+		t.Fatalf("releases = %+v, want only the deployed releases redis and db", releases)
+	}
+
+	redis := byName["redis"]
+	if redis.Status != "deployed" || redis.Version != 1 {
+		t.Errorf("redis status = %s, revision = %d, want the defaults deployed and 1", redis.Status, redis.Version)
+	}
+	if redis.Chart.AppVersion != "7.0.5" || !strings.Contains(string(redis.Chart.DependenciesFile), "postgresql") {
+		t.Errorf("redis chart = %+v, want the app version and dependencies file of the fixture", redis.Chart)
+	}
+	if db := byName["db"]; db.Version != 3 {
+		t.Errorf("db revision = %d, want 3", db.Version)
+	}
+}
+
+func TestLoadFixtureErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		fixture   string
+		wantError string
+	}{
+		{
+			name:      "missing name",
+			fixture:   "releases:\n  - namespace: shared\n    chart:\n      name: redis\n",
+			wantError: "invalid release #1",
+		},
+		{
+			name:      "missing namespace",
+			fixture:   "releases:\n  - name: redis\n    chart:\n      name: redis\n",
+			wantError: "invalid release #1",
+		},
+		{
+			name:      "missing chart name",
+			fixture:   "releases:\n  - name: redis\n    namespace: shared\n    chart:\n      version: 17.3.0\n",
+			wantError: "invalid release #1",
+		},
+		{
+			name:      "unknown release field",
+			fixture:   "releases:\n  - name: redis\n    namespace: shared\n    chart:\n      name: redis\n      appversion: 7.0.5\n",
+			wantError: "field appversion not found",
+		},
+		{
+			name:      "unknown top-level field",
+			fixture:   "release:\n  - name: redis\n",
+			wantError: "field release not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadFixture(writeFixture(t, tt.fixture))
+			if err == nil || !strings.Contains(err.Error(), tt.wantError) {
+				t.Errorf("LoadFixture() error = %v, want error containing %q", err, tt.wantError)
+			}
+		})
+	}
+}
+
+func TestLoadFixtureEmpty(t *testing.T) {
+	source, err := LoadFixture(writeFixture(t, ""))
+	if err != nil {
+		t.Fatalf("LoadFixture() error = %v", err)
+	}
+
+	releases, accessErrors, err := source.GetReleases(context.Background(), "")
+	if err != nil || len(releases) != 0 || len(accessErrors) != 0 {
+		t.Errorf("GetReleases() = %v, %v, %v, want no releases", releases, accessErrors, err)
+	}
+}
@@ -0,0 +1,31 @@
+package helm
+
+import (
+	"fmt"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/cli"
+	"helm.sh/helm/v3/pkg/registry"
+)
+
+// PullChart pulls the gzipped chart archive of an oci:// reference from its registry, using
+// the credentials stored by helm registry login
+func PullChart(chartRef string) ([]byte, error) {
+	settings := cli.New()
+
+	client, err := registry.NewClient(registry.ClientOptCredentialsFile(settings.RegistryConfig))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create registry client: %v", err)
+	}
+
+	result, err := client.Pull(strings.TrimPrefix(chartRef, "oci://"))
+	if err != nil {
+		return nil, err
+	}
+
+	if result.Chart == nil || len(result.Chart.Data) == 0 {
+		return nil, fmt.Errorf("no chart content in %s", chartRef)
+	}
+
+	return result.Chart.Data, nil
+}
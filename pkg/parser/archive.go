@@ -0,0 +1,160 @@
+package parser
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxArchiveSize limits the decompressed size of a chart archive, matching Helm's default
+const maxArchiveSize = 100 << 20
+
+// loadArchive reads a gzipped chart archive into memory. Helm packages all chart files below
+// a top-level directory named after the chart, which is stripped from the file names.
+func loadArchive(data []byte) (fs.FS, error) {
+	gzipReader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read chart archive: %v", err)
+	}
+	defer gzipReader.Close()
+
+	files := make(memFS)
+	var total int64
+	tarReader := tar.NewReader(gzipReader)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read chart archive: %v", err)
+		}
+
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		// Strip the top-level chart directory
+		name := path.Clean(strings.TrimPrefix(header.Name, "/"))
+		parts := strings.SplitN(name, "/", 2)
+		if len(parts) < 2 || strings.HasPrefix(name, "../") {
+			continue
+		}
+
+		total += header.Size
+		if total > maxArchiveSize {
+			return nil, fmt.Errorf("chart archive exceeds the maximum size of %d bytes", maxArchiveSize)
+		}
+
+		content, err := io.ReadAll(io.LimitReader(tarReader, header.Size))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s from chart archive: %v", header.Name, err)
+		}
+		files[parts[1]] = content
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("chart archive is empty")
+	}
+
+	return files, nil
+}
+
+// memFS is a read-only in-memory file system mapping slash-separated paths to file contents
+type memFS map[string][]byte
+
+// Open opens the named file
+func (m memFS) Open(name string) (fs.File, error) {
+	data, ok := m[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return &memFile{info: memFileInfo{name: path.Base(name), size: int64(len(data))}, reader: bytes.NewReader(data)}, nil
+}
+
+// ReadFile returns the contents of the named file
+func (m memFS) ReadFile(name string) ([]byte, error) {
+	data, ok := m[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: fs.ErrNotExist}
+	}
+	return bytes.Clone(data), nil
+}
+
+// ReadDir returns the entries of the named directory sorted by name
+func (m memFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	prefix := ""
+	if name != "." {
+		prefix = name + "/"
+	}
+
+	entries := make(map[string]fs.DirEntry)
+	for filePath, data := range m {
+		if !strings.HasPrefix(filePath, prefix) {
+			continue
+		}
+		rest := strings.TrimPrefix(filePath, prefix)
+		if child, _, isDir := strings.Cut(rest, "/"); isDir {
+			entries[child] = memFileInfo{name: child, dir: true}
+		} else {
+			entries[child] = memFileInfo{name: child, size: int64(len(data))}
+		}
+	}
+
+	if len(entries) == 0 {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+
+	result := make([]fs.DirEntry, 0, len(entries))
+	for _, entry := range entries {
+		result = append(result, entry)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name() < result[j].Name() })
+
+	return result, nil
+}
+
+// memFile is an open file of a memFS
+type memFile struct {
+	info   memFileInfo
+	reader *bytes.Reader
+}
+
+func (f *memFile) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *memFile) Read(b []byte) (int, error) { return f.reader.Read(b) }
+func (f *memFile) Close() error               { return nil }
+
+// memFileInfo describes a file or directory of a memFS
+type memFileInfo struct {
+	name string
+	size int64
+	dir  bool
+}
+
+func (i memFileInfo) Name() string               { return i.name }
+func (i memFileInfo) Size() int64                { return i.size }
+func (i memFileInfo) ModTime() time.Time         { return time.Time{} }
+func (i memFileInfo) IsDir() bool                { return i.dir }
+func (i memFileInfo) Sys() any                   { return nil }
+func (i memFileInfo) Type() fs.FileMode          { return i.Mode().Type() }
+func (i memFileInfo) Info() (fs.FileInfo, error) { return i, nil }
+
+func (i memFileInfo) Mode() fs.FileMode {
+	if i.dir {
+		return fs.ModeDir | 0o555
+	}
+	return 0o444
+}
+
+// isNotExist reports whether the error means the file does not exist
+func isNotExist(err error) bool {
+	return errors.Is(err, fs.ErrNotExist)
+}
@@ -0,0 +1,138 @@
+package parser
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// OCIScheme is the URL scheme of chart references stored in OCI registries
+	OCIScheme = "oci://"
+
+	// chartLayerMediaType is the media type of the chart archive layer of a Helm OCI artifact
+	chartLayerMediaType = "application/vnd.cncf.helm.chart.content.v1.tar+gzip"
+
+	// refNameAnnotation is the OCI image layout annotation holding a manifest's reference name
+	refNameAnnotation = "org.opencontainers.image.ref.name"
+)
+
+// ociDescriptor is an OCI content descriptor
+type ociDescriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// ociIndex is the index.json of an OCI image layout
+type ociIndex struct {
+	Manifests []ociDescriptor `json:"manifests"`
+}
+
+// ociManifest is an OCI image manifest
+type ociManifest struct {
+	Layers []ociDescriptor `json:"layers"`
+}
+
+// IsOCIReference reports whether the chart reference points to an OCI registry
+func IsOCIReference(chartRef string) bool {
+	return strings.HasPrefix(chartRef, OCIScheme)
+}
+
+// readOCILayout reads the chart archive of an OCI reference from a local OCI image layout.
+// The manifest is selected by digest for "@sha256:..." references and otherwise by its
+// reference name annotation, which may hold either the tag or the full reference.
+func readOCILayout(layoutDir, chartRef string) ([]byte, error) {
+	indexData, err := os.ReadFile(filepath.Join(layoutDir, "index.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read OCI layout index: %v", err)
+	}
+
+	var index ociIndex
+	if err := json.Unmarshal(indexData, &index); err != nil {
+		return nil, fmt.Errorf("failed to parse OCI layout index: %v", err)
+	}
+
+	manifestDescriptor, err := findOCIManifest(index, chartRef)
+	if err != nil {
+		return nil, err
+	}
+
+	manifestData, err := readOCIBlob(layoutDir, manifestDescriptor.Digest)
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest ociManifest
+	if err := json.Unmarshal(manifestData, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse OCI manifest %s: %v", manifestDescriptor.Digest, err)
+	}
+
+	for _, layer := range manifest.Layers {
+		if layer.MediaType == chartLayerMediaType {
+			return readOCIBlob(layoutDir, layer.Digest)
+		}
+	}
+
+	return nil, fmt.Errorf("OCI manifest %s has no Helm chart layer", manifestDescriptor.Digest)
+}
+
+// findOCIManifest finds the manifest descriptor matching the chart reference
+func findOCIManifest(index ociIndex, chartRef string) (ociDescriptor, error) {
+	ref := strings.TrimPrefix(chartRef, OCIScheme)
+
+	if repository, digest, ok := strings.Cut(ref, "@"); ok {
+		for _, manifest := range index.Manifests {
+			if manifest.Digest == digest {
+				return manifest, nil
+			}
+		}
+		return ociDescriptor{}, fmt.Errorf("digest %s of %s not found in OCI layout", digest, repository)
+	}
+
+	// The tag follows the last colon after the last slash; a colon before it belongs to a registry port
+	tag := ""
+	if i := strings.LastIndex(ref, ":"); i > strings.LastIndex(ref, "/") {
+		tag = ref[i+1:]
+	}
+
+	for _, manifest := range index.Manifests {
+		name := manifest.Annotations[refNameAnnotation]
+		if name != "" && (name == ref || name == tag) {
+			return manifest, nil
+		}
+	}
+
+	// Without a tag, a layout holding a single manifest is unambiguous
+	if tag == "" && len(index.Manifests) == 1 {
+		return index.Manifests[0], nil
+	}
+
+	return ociDescriptor{}, fmt.Errorf("reference %s not found in OCI layout", chartRef)
+}
+
+// readOCIBlob reads a blob from an OCI layout and verifies its sha256 digest
+func readOCIBlob(layoutDir, digest string) ([]byte, error) {
+	algorithm, encoded, ok := strings.Cut(digest, ":")
+	if !ok || algorithm == "" || encoded == "" || strings.ContainsAny(encoded, `/\.`) {
+		return nil, fmt.Errorf("invalid OCI digest '%s'", digest)
+	}
+
+	data, err := os.ReadFile(filepath.Join(layoutDir, "blobs", algorithm, encoded))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read OCI blob %s: %v", digest, err)
+	}
+
+	if algorithm == "sha256" {
+		sum := sha256.Sum256(data)
+		if hex.EncodeToString(sum[:]) != encoded {
+			return nil, fmt.Errorf("OCI blob %s does not match its digest", digest)
+		}
+	}
+
+	return data, nil
+}
@@ -1,9 +1,11 @@
 package parser
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
-	"os"
-	"path/filepath"
+	"io"
+	"regexp"
 	"strings"
 
 	"github.com/Masterminds/semver/v3"
@@ -13,22 +15,114 @@ import (
 )
 
 // Parser handles parsing and validation of dependencies.yaml files
-type Parser struct{}
+type Parser struct {
+	charts    map[string]*chartSource
+	pull      PullFunc
+	ociLayout string
+}
 
 // NewParser creates a new Parser instance
 func NewParser() *Parser {
-	return &Parser{}
+	return &Parser{
+		charts: make(map[string]*chartSource),
+	}
+}
+
+// SetPullFunc sets the function used to pull charts referenced with oci://
+func (p *Parser) SetPullFunc(pull PullFunc) {
+	p.pull = pull
+}
+
+// SetOCILayout sets a local OCI image layout directory to read oci:// charts from
+// instead of pulling them from a registry
+func (p *Parser) SetOCILayout(layoutDir string) {
+	p.ociLayout = layoutDir
 }
 
-// ParseDependencies reads and parses dependencies.yaml from the given chart path
+// DependenciesAnnotation is the Chart.yaml annotation that may hold dependency requirements
+// as embedded YAML, using the same structure as dependencies.yaml
+const DependenciesAnnotation = "helm-depcheck/dependencies"
+
+// ParseDependencies reads and parses the dependency requirements of the given chart, which
+// may be a chart directory, a packaged chart archive or an oci:// reference.
+// Requirements are read from dependencies.yaml and from the helm-depcheck/dependencies
+// annotation in Chart.yaml. When both exist their entries are merged, dependencies.yaml
+// first, and a dependency name declared in both sources is reported as a duplicate.
+// Every problem found is reported at once as types.ValidationErrors.
 func (p *Parser) ParseDependencies(chartPath string) (*types.DependenciesFile, error) {
-	dependenciesPath := filepath.Join(chartPath, "dependencies.yaml")
+	source, err := p.loadChart(chartPath)
+	if err != nil {
+		return nil, types.NewValidationError(
+			types.ErrorTypeInvalidDependencyFile,
+			"",
+			err.Error(),
+			types.ErrorDetails{File: chartPath},
+		)
+	}
+
+	return p.parseChartDependencies(source)
+}
+
+// parseChartDependencies reads the requirements of a single chart from dependencies.yaml
+// and the Chart.yaml annotation
+func (p *Parser) parseChartDependencies(source *chartSource) (*types.DependenciesFile, error) {
+	var errs types.ValidationErrors
+
+	fileDeps, err := p.parseDependenciesFile(source)
+	errs = errs.Append(err)
+
+	annotationDeps, err := p.parseDependenciesAnnotation(source)
+	errs = errs.Append(err)
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	return p.mergeDependencies(fileDeps, annotationDeps)
+}
+
+// ParseReleaseDependencies parses the requirements stored with the chart of a deployed
+// release, from its dependencies.yaml file and its Chart.yaml annotation
+func (p *Parser) ParseReleaseDependencies(release types.Release) (*types.DependenciesFile, error) {
+	label := fmt.Sprintf("release %s/%s", release.Namespace, release.Name)
+	fileDeps := &types.DependenciesFile{Dependencies: []types.Dependency{}}
+	annotationDeps := &types.DependenciesFile{Dependencies: []types.Dependency{}}
+	var errs types.ValidationErrors
+
+	if len(release.Chart.DependenciesFile) > 0 {
+		deps, err := p.ParseDependenciesData(release.Chart.DependenciesFile, label+" dependencies.yaml")
+		if err != nil {
+			errs = errs.Append(err)
+		} else {
+			fileDeps = deps
+		}
+	}
+
+	if release.Chart.DependenciesAnnotation != "" {
+		deps, err := p.ParseDependenciesData([]byte(release.Chart.DependenciesAnnotation), annotationSource(label+" Chart.yaml"))
+		if err != nil {
+			errs = errs.Append(err)
+		} else {
+			annotationDeps = deps
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	return p.mergeDependencies(fileDeps, annotationDeps)
+}
+
+// parseDependenciesFile reads and parses dependencies.yaml of the given chart
+func (p *Parser) parseDependenciesFile(source *chartSource) (*types.DependenciesFile, error) {
+	dependenciesPath := source.path("dependencies.yaml")
 
 	// Check if dependencies.yaml exists
-	data, err := os.ReadFile(dependenciesPath)
+	data, err := source.readFile("dependencies.yaml")
 	if err != nil {
 		// If file doesn't exist, return empty dependencies (no error)
-		if strings.Contains(err.Error(), "no such file") || strings.Contains(err.Error(), "cannot find") {
+		if isNotExist(err) {
 			return &types.DependenciesFile{Dependencies: []types.Dependency{}}, nil
 		}
 		return nil, types.NewValidationError(
@@ -39,89 +133,472 @@ func (p *Parser) ParseDependencies(chartPath string) (*types.DependenciesFile, e
 		)
 	}
 
-	var deps types.DependenciesFile
-	if err := yaml.Unmarshal(data, &deps); err != nil {
+	return p.ParseDependenciesData(data, dependenciesPath)
+}
+
+// parseDependenciesAnnotation reads dependency requirements from the Chart.yaml annotation
+func (p *Parser) parseDependenciesAnnotation(source *chartSource) (*types.DependenciesFile, error) {
+	chartYamlPath := source.path("Chart.yaml")
+
+	data, err := source.readFile("Chart.yaml")
+	if err != nil {
+		// A missing Chart.yaml is reported by ValidateChartPath
+		return &types.DependenciesFile{Dependencies: []types.Dependency{}}, nil
+	}
+
+	var root yaml.Node
+	var chart struct {
+		Annotations map[string]string `yaml:"annotations"`
+	}
+	if err := yaml.Unmarshal(data, &root); err == nil {
+		err = root.Decode(&chart)
+	}
+	if err != nil {
+		return nil, types.NewValidationError(
+			types.ErrorTypeInvalidDependencyFile,
+			"",
+			fmt.Sprintf("failed to parse Chart.yaml: %v", err),
+			types.ErrorDetails{
+				File: chartYamlPath,
+				Line: extractLineFromYAMLError(err),
+			},
+		)
+	}
+
+	annotation, ok := chart.Annotations[DependenciesAnnotation]
+	if !ok {
+		return &types.DependenciesFile{Dependencies: []types.Dependency{}}, nil
+	}
+
+	lineOffset, columnOffset := annotationOffset(data, &root)
+	return p.parseDependenciesData([]byte(annotation), annotationSource(chartYamlPath), lineOffset, columnOffset)
+}
+
+// ParseDependenciesData parses and validates dependency requirements from raw YAML. The
+// source names the file the data came from and is recorded on every parsed entry.
+func (p *Parser) ParseDependenciesData(data []byte, source string) (*types.DependenciesFile, error) {
+	return p.parseDependenciesData(data, source, 0, 0)
+}
+
+// parseDependenciesData parses and validates dependency requirements from raw YAML. The
+// offsets move reported positions of YAML embedded in another file to that file.
+func (p *Parser) parseDependenciesData(data []byte, source string, lineOffset, columnOffset int) (*types.DependenciesFile, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
 		line := extractLineFromYAMLError(err)
+		if line > 0 {
+			line += lineOffset
+		}
 		return nil, types.NewValidationError(
 			types.ErrorTypeInvalidDependencyFile,
 			"",
 			fmt.Sprintf("failed to parse YAML: %v", err),
 			types.ErrorDetails{
-				File: dependenciesPath,
+				File: source,
 				Line: line,
 			},
 		)
 	}
+	shiftPositions(&root, lineOffset, columnOffset)
+
+	// Unknown fields don't prevent decoding, so they are reported along with the
+	// validation errors; any other decoding error leaves nothing to validate
+	deps, errs := p.decodeDependencies(data, &root, source, lineOffset)
+	if deps == nil {
+		return nil, errs
+	}
+
+	// Locate the entries so errors point at the offending fields
+	var document *yaml.Node
+	if len(root.Content) > 0 {
+		document = root.Content[0]
+	}
+
+	dependencyNodes := sequenceItems(document, "dependencies")
+	conflictNodes := sequenceItems(document, "conflicts")
 
 	// Validate the parsed dependencies
-	if err := p.validateDependencies(&deps, dependenciesPath); err != nil {
-		return nil, err
+	errs = append(errs, p.validateDependencies(deps, dependencyNodes, source)...)
+
+	// Validate the conflict rules
+	errs = append(errs, p.validateConflicts(deps, conflictNodes, source)...)
+
+	if len(errs) > 0 {
+		errs.Sort()
+		return nil, errs
+	}
+
+	for i := range deps.Dependencies {
+		dep := &deps.Dependencies[i]
+		locate(dep, source, nodeAt(dependencyNodes, i))
+		alternativeNodes := sequenceItems(nodeAt(dependencyNodes, i), "anyOf")
+		for j := range dep.AnyOf {
+			locate(&dep.AnyOf[j], source, nodeAt(alternativeNodes, j))
+		}
+	}
+	for i := range deps.Conflicts {
+		locate(&deps.Conflicts[i].Dependency, source, nodeAt(conflictNodes, i))
 	}
 
-	return &deps, nil
+	return deps, nil
 }
 
-// validateDependencies validates the structure and content of dependencies
-func (p *Parser) validateDependencies(deps *types.DependenciesFile, filePath string) error {
-	seenNames := make(map[string]bool)
+// decodeDependencies decodes the requirements while rejecting fields that are not part of
+// the dependencies.yaml format. Unknown fields are returned as errors alongside the decoded
+// requirements; other decoding errors are returned without requirements.
+func (p *Parser) decodeDependencies(data []byte, root *yaml.Node, source string, lineOffset int) (*types.DependenciesFile, types.ValidationErrors) {
+	var deps types.DependenciesFile
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
 
-	for i, dep := range deps.Dependencies {
-		lineNumber := i + 2 // Approximate line number (accounting for YAML structure)
+	err := decoder.Decode(&deps)
+	if err == io.EOF {
+		err = nil
+	}
+	if deps.Dependencies == nil {
+		deps.Dependencies = []types.Dependency{}
+	}
+	if err == nil {
+		return &deps, nil
+	}
 
-		// Validate required fields
-		if strings.TrimSpace(dep.Name) == "" {
-			return types.NewValidationError(
+	typeErr, ok := err.(*yaml.TypeError)
+	if !ok {
+		line := extractLineFromYAMLError(err)
+		if line > 0 {
+			line += lineOffset
+		}
+		return nil, types.ValidationErrors{types.NewValidationError(
+			types.ErrorTypeInvalidDependencyFile,
+			"",
+			fmt.Sprintf("failed to parse YAML: %v", err),
+			types.ErrorDetails{File: source, Line: line},
+		)}
+	}
+
+	var errs types.ValidationErrors
+	onlyUnknownFields := true
+	for _, message := range typeErr.Errors {
+		details := types.ErrorDetails{File: source}
+		if line := extractLineFromYAMLError(errors.New(message)); line > 0 {
+			details.Line = line + lineOffset
+		}
+
+		if field, ok := unknownField(message); ok {
+			if key := findKey(root, field, details.Line); key != nil {
+				details.Column = key.Column
+			}
+			unknown := fmt.Sprintf("unknown field '%s'", field)
+			if suggestion := suggestField(field); suggestion != "" {
+				unknown += fmt.Sprintf(" (did you mean '%s'?)", suggestion)
+			}
+			errs = append(errs, types.NewValidationError(
 				types.ErrorTypeInvalidDependencyFile,
 				"",
-				"dependency name cannot be empty",
-				types.ErrorDetails{
-					File: filePath,
-					Line: lineNumber,
-				},
-			)
+				unknown,
+				details,
+			))
+			continue
 		}
 
-		if strings.TrimSpace(dep.Version) == "" {
-			return types.NewValidationError(
-				types.ErrorTypeInvalidDependencyFile,
-				dep.Name,
-				"dependency version cannot be empty",
-				types.ErrorDetails{
-					File: filePath,
-					Line: lineNumber,
-				},
-			)
+		onlyUnknownFields = false
+		errs = append(errs, types.NewValidationError(
+			types.ErrorTypeInvalidDependencyFile,
+			"",
+			fmt.Sprintf("failed to parse YAML: %s", message),
+			details,
+		))
+	}
+
+	if !onlyUnknownFields {
+		return nil, errs
+	}
+	return &deps, errs
+}
+
+// mergeDependencies merges requirements from several sources in order, rejecting
+// dependency names that are declared by more than one source
+func (p *Parser) mergeDependencies(sources ...*types.DependenciesFile) (*types.DependenciesFile, error) {
+	merged := &types.DependenciesFile{Dependencies: []types.Dependency{}}
+	declared := make(map[string]types.Dependency)
+	var errs types.ValidationErrors
+
+	for _, deps := range sources {
+		for _, dep := range deps.Dependencies {
+			name := dep.DisplayName()
+			if previous, ok := declared[name]; ok {
+				errs = append(errs, types.NewValidationError(
+					types.ErrorTypeInvalidDependencyFile,
+					name,
+					fmt.Sprintf("duplicate dependency name (already declared in %s)", declarationPosition(previous)),
+					declarationDetails(dep),
+				))
+				continue
+			}
+			declared[name] = dep
+			merged.Dependencies = append(merged.Dependencies, dep)
+		}
+		merged.Conflicts = append(merged.Conflicts, deps.Conflicts...)
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	return merged, nil
+}
+
+// annotationSource returns the source label of requirements embedded in a Chart.yaml annotation
+func annotationSource(chartYamlPath string) string {
+	return fmt.Sprintf("%s (annotation %s)", chartYamlPath, DependenciesAnnotation)
+}
+
+// validateDependencies validates the structure and content of dependencies. The nodes are
+// the YAML mapping nodes of the entries, in the same order.
+func (p *Parser) validateDependencies(deps *types.DependenciesFile, nodes []*yaml.Node, filePath string) types.ValidationErrors {
+	var errs types.ValidationErrors
+	seenNames := make(map[string]bool)
+
+	for i, dep := range deps.Dependencies {
+		node := nodeAt(nodes, i)
+
+		if dep.IsGroup() {
+			errs = append(errs, p.validateGroup(dep, node, filePath)...)
+		} else {
+			errs = append(errs, p.validateDependency(dep, node, filePath)...)
 		}
 
 		// Check for duplicate names
-		if seenNames[dep.Name] {
-			return types.NewValidationError(
+		name := dep.DisplayName()
+		if name == "" {
+			continue
+		}
+		if seenNames[name] {
+			errs = append(errs, types.NewValidationError(
 				types.ErrorTypeInvalidDependencyFile,
-				dep.Name,
+				name,
 				"duplicate dependency name",
-				types.ErrorDetails{
-					File: filePath,
-					Line: lineNumber,
-				},
-			)
+				fieldDetails(filePath, node, "name"),
+			))
 		}
-		seenNames[dep.Name] = true
+		seenNames[name] = true
+	}
 
+	return errs
+}
+
+// validateDependency validates a single dependency entry
+func (p *Parser) validateDependency(dep types.Dependency, node *yaml.Node, filePath string) types.ValidationErrors {
+	var errs types.ValidationErrors
+
+	// Validate required fields
+	if strings.TrimSpace(dep.Name) == "" {
+		errs = append(errs, types.NewValidationError(
+			types.ErrorTypeInvalidDependencyFile,
+			"",
+			"dependency name cannot be empty",
+			fieldDetails(filePath, node, "name"),
+		))
+	}
+
+	if strings.TrimSpace(dep.Version) == "" {
+		errs = append(errs, types.NewValidationError(
+			types.ErrorTypeInvalidDependencyFile,
+			dep.Name,
+			"dependency version cannot be empty",
+			fieldDetails(filePath, node, "version"),
+		))
+	} else if err := p.validateVersionConstraint(dep.Version); err != nil {
 		// Validate version constraint
-		if err := p.validateVersionConstraint(dep.Version); err != nil {
-			return types.NewValidationError(
+		errs = append(errs, types.NewValidationError(
+			types.ErrorTypeInvalidVersionConstraint,
+			dep.Name,
+			err.Error(),
+			fieldDetails(filePath, node, "version"),
+		))
+	}
+
+	// Validate app version constraint
+	if dep.AppVersion != "" {
+		if err := p.validateVersionConstraint(dep.AppVersion); err != nil {
+			errs = append(errs, types.NewValidationError(
 				types.ErrorTypeInvalidVersionConstraint,
 				dep.Name,
+				fmt.Sprintf("appVersion: %v", err),
+				fieldDetails(filePath, node, "appVersion"),
+			))
+		}
+	}
+
+	// Validate severity
+	if err := p.validateSeverity(dep); err != nil {
+		errs = append(errs, types.NewValidationError(
+			types.ErrorTypeInvalidDependencyFile,
+			dep.Name,
+			err.Error(),
+			fieldDetails(filePath, node, "severity"),
+		))
+	}
+
+	// Validate namespace and release name selectors
+	if field, err := p.validateSelectors(dep); err != nil {
+		errs = append(errs, types.NewValidationError(
+			types.ErrorTypeInvalidDependencyFile,
+			dep.Name,
+			err.Error(),
+			fieldDetails(filePath, node, field),
+		))
+	}
+
+	return errs
+}
+
+// validateGroup validates an anyOf group entry and each of its alternatives
+func (p *Parser) validateGroup(group types.Dependency, node *yaml.Node, filePath string) types.ValidationErrors {
+	var errs types.ValidationErrors
+	name := group.DisplayName()
+
+	if len(group.AnyOf) < 2 {
+		errs = append(errs, types.NewValidationError(
+			types.ErrorTypeInvalidDependencyFile,
+			name,
+			"anyOf group must list at least two alternatives",
+			fieldDetails(filePath, node, "anyOf"),
+		))
+	}
+
+	// Constraints belong to the alternatives, the group only carries its name and severity
+	constraintFields := map[string]string{
+		"version":            group.Version,
+		"appVersion":         group.AppVersion,
+		"scope":              group.Scope,
+		"namespace":          group.Namespace,
+		"namespacePattern":   group.NamespacePattern,
+		"releaseName":        group.ReleaseName,
+		"releaseNamePattern": group.ReleaseNamePattern,
+	}
+	for _, field := range []string{"version", "appVersion", "scope", "namespace", "namespacePattern", "releaseName", "releaseNamePattern"} {
+		if constraintFields[field] != "" {
+			errs = append(errs, types.NewValidationError(
+				types.ErrorTypeInvalidDependencyFile,
+				name,
+				"anyOf group can only set name, optional and severity; constraints belong to its alternatives",
+				fieldDetails(filePath, node, field),
+			))
+		}
+	}
+
+	if err := p.validateSeverity(group); err != nil {
+		errs = append(errs, types.NewValidationError(
+			types.ErrorTypeInvalidDependencyFile,
+			name,
+			err.Error(),
+			fieldDetails(filePath, node, "severity"),
+		))
+	}
+
+	alternativeNodes := sequenceItems(node, "anyOf")
+	for i, alternative := range group.AnyOf {
+		alternativeNode := nodeAt(alternativeNodes, i)
+
+		if alternative.IsGroup() || alternative.Optional || alternative.Severity != "" {
+			field := "severity"
+			if alternative.IsGroup() {
+				field = "anyOf"
+			} else if alternative.Optional {
+				field = "optional"
+			}
+			errs = append(errs, types.NewValidationError(
+				types.ErrorTypeInvalidDependencyFile,
+				name,
+				fmt.Sprintf("alternative '%s' cannot set anyOf, optional or severity", alternative.Name),
+				fieldDetails(filePath, alternativeNode, field),
+			))
+			continue
+		}
+
+		errs = append(errs, p.validateDependency(alternative, alternativeNode, filePath)...)
+	}
+
+	return errs
+}
+
+// validateConflicts validates the conflict rules. Conflicts use the dependency selectors,
+// but their version constraint is optional and they cannot be groups or optional.
+func (p *Parser) validateConflicts(deps *types.DependenciesFile, nodes []*yaml.Node, filePath string) types.ValidationErrors {
+	var errs types.ValidationErrors
+
+	for i, conflict := range deps.Conflicts {
+		node := nodeAt(nodes, i)
+
+		if strings.TrimSpace(conflict.Name) == "" {
+			errs = append(errs, types.NewValidationError(
+				types.ErrorTypeInvalidDependencyFile,
+				"",
+				"conflict name cannot be empty",
+				fieldDetails(filePath, node, "name"),
+			))
+		}
+
+		if conflict.IsGroup() || conflict.Optional {
+			field := "optional"
+			if conflict.IsGroup() {
+				field = "anyOf"
+			}
+			errs = append(errs, types.NewValidationError(
+				types.ErrorTypeInvalidDependencyFile,
+				conflict.Name,
+				"conflicts cannot set anyOf or optional",
+				fieldDetails(filePath, node, field),
+			))
+		}
+
+		constraints := map[string]string{"version": conflict.Version, "appVersion": conflict.AppVersion}
+		for _, field := range []string{"version", "appVersion"} {
+			constraint := constraints[field]
+			if constraint == "" {
+				continue
+			}
+			if err := p.validateVersionConstraint(constraint); err != nil {
+				errs = append(errs, types.NewValidationError(
+					types.ErrorTypeInvalidVersionConstraint,
+					conflict.Name,
+					err.Error(),
+					fieldDetails(filePath, node, field),
+				))
+			}
+		}
+
+		if err := p.validateSeverity(conflict.Dependency); err != nil {
+			errs = append(errs, types.NewValidationError(
+				types.ErrorTypeInvalidDependencyFile,
+				conflict.Name,
 				err.Error(),
-				types.ErrorDetails{
-					File: filePath,
-					Line: lineNumber,
-				},
-			)
+				fieldDetails(filePath, node, "severity"),
+			))
+		}
+
+		if field, err := p.validateSelectors(conflict.Dependency); err != nil {
+			errs = append(errs, types.NewValidationError(
+				types.ErrorTypeInvalidDependencyFile,
+				conflict.Name,
+				err.Error(),
+				fieldDetails(filePath, node, field),
+			))
 		}
 	}
 
-	return nil
+	return errs
+}
+
+// validateSeverity validates the optional severity of a dependency
+func (p *Parser) validateSeverity(dep types.Dependency) error {
+	switch dep.Severity {
+	case "", types.SeverityError, types.SeverityWarning:
+		return nil
+	default:
+		return fmt.Errorf("invalid severity '%s': must be %s or %s", dep.Severity, types.SeverityError, types.SeverityWarning)
+	}
 }
 
 // validateVersionConstraint validates that a version constraint is valid semver
@@ -140,26 +617,57 @@ func (p *Parser) validateVersionConstraint(constraint string) error {
 	return nil
 }
 
-// extractLineFromYAMLError attempts to extract line number from YAML parsing errors
-func extractLineFromYAMLError(err error) int {
-	errStr := err.Error()
+// validateSelectors validates the optional namespace and release name selectors of a
+// dependency. On failure it also returns the name of the offending field.
+func (p *Parser) validateSelectors(dep types.Dependency) (string, error) {
+	if dep.Namespace != "" && dep.NamespacePattern != "" {
+		return "namespacePattern", fmt.Errorf("namespace and namespacePattern are mutually exclusive")
+	}
+
+	if dep.ReleaseName != "" && dep.ReleaseNamePattern != "" {
+		return "releaseNamePattern", fmt.Errorf("releaseName and releaseNamePattern are mutually exclusive")
+	}
 
-	// Try to extract line number from common YAML error patterns
-	if strings.Contains(errStr, "line ") {
-		var line int
-		if n, parseErr := fmt.Sscanf(errStr, "%*s line %d", &line); parseErr == nil && n == 1 {
-			return line
+	hasNamespaceConstraint := dep.Namespace != "" || dep.NamespacePattern != ""
+	switch dep.Scope {
+	case "":
+		// Scope is derived from the namespace constraints
+	case types.ScopeNamespaces:
+		if !hasNamespaceConstraint {
+			return "scope", fmt.Errorf("scope %s requires namespace or namespacePattern", types.ScopeNamespaces)
+		}
+	case types.ScopeCluster, types.ScopeSameNamespace:
+		if hasNamespaceConstraint {
+			return "scope", fmt.Errorf("namespace and namespacePattern cannot be used with scope %s", dep.Scope)
 		}
+	default:
+		return "scope", fmt.Errorf("invalid scope '%s': must be one of %s, %s, %s",
+			dep.Scope, types.ScopeSameNamespace, types.ScopeCluster, types.ScopeNamespaces)
 	}
 
-	return 0 // Unknown line
+	if dep.NamespacePattern != "" {
+		if _, err := regexp.Compile(dep.NamespacePattern); err != nil {
+			return "namespacePattern", fmt.Errorf("invalid namespacePattern '%s': %v", dep.NamespacePattern, err)
+		}
+	}
+
+	if dep.ReleaseNamePattern != "" {
+		if _, err := regexp.Compile(dep.ReleaseNamePattern); err != nil {
+			return "releaseNamePattern", fmt.Errorf("invalid releaseNamePattern '%s': %v", dep.ReleaseNamePattern, err)
+		}
+	}
+
+	return "", nil
 }
 
 // ValidateChartPath validates that the given path contains a valid Helm chart
 func (p *Parser) ValidateChartPath(chartPath string) error {
-	chartYamlPath := filepath.Join(chartPath, "Chart.yaml")
+	source, err := p.loadChart(chartPath)
+	if err != nil {
+		return err
+	}
 
-	if _, err := os.ReadFile(chartYamlPath); err != nil {
+	if _, err := source.readFile("Chart.yaml"); err != nil {
 		return fmt.Errorf("invalid chart path '%s': Chart.yaml not found or not readable", chartPath)
 	}
 
@@ -168,9 +676,12 @@ func (p *Parser) ValidateChartPath(chartPath string) error {
 
 // GetChartInfo reads basic chart information from Chart.yaml
 func (p *Parser) GetChartInfo(chartPath string) (*types.ChartInfo, error) {
-	chartYamlPath := filepath.Join(chartPath, "Chart.yaml")
+	source, err := p.loadChart(chartPath)
+	if err != nil {
+		return nil, err
+	}
 
-	data, err := os.ReadFile(chartYamlPath)
+	data, err := source.readFile("Chart.yaml")
 	if err != nil {
 		return nil, fmt.Errorf("failed to read Chart.yaml: %v", err)
 	}
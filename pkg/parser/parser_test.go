@@ -0,0 +1,585 @@
+package parser
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"helm-depcheck/pkg/types"
+)
+
+// writeChart creates a chart directory named app with the given files
+func writeChart(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir := filepath.Join(t.TempDir(), "app")
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// chartYAML is a Chart.yaml without the dependencies annotation
+const chartYAML = "apiVersion: v2\nname: app\nversion: 1.0.0\n"
+
+// annotatedChartYAML returns a Chart.yaml holding the given requirements in the
+// dependencies annotation
+func annotatedChartYAML(requirements string) string {
+	indented := "    " + strings.ReplaceAll(strings.TrimSuffix(requirements, "\n"), "\n", "\n    ")
+	return chartYAML + "annotations:\n  helm-depcheck/dependencies: |\n" + indented + "\n"
+}
+
+// checkErrors verifies that err holds one validation error per wanted message, each
+// containing the wanted text
+func checkErrors(t *testing.T, err error, want []string) {
+	t.Helper()
+
+	if err == nil {
+		t.Fatalf("error = nil, want %d errors", len(want))
+	}
+	errs := types.ValidationErrors(nil).Append(err)
+	if len(errs) != len(want) {
+		t.Fatalf("got %d errors, want %d:\n%v", len(errs), len(want), err)
+	}
+	for i, message := range want {
+		if !strings.Contains(errs[i].Error(), message) {
+			t.Errorf("error %d = %q, want it to contain %q", i, errs[i].Error(), message)
+		}
+	}
+}
+
+// dependencyNames returns the display names of the parsed dependencies
+func dependencyNames(deps *types.DependenciesFile) []string {
+	names := []string{}
+	for _, dep := range deps.Dependencies {
+		names = append(names, dep.DisplayName())
+	}
+	return names
+}
+
+func TestParseDependencies(t *testing.T) {
+	tests := []struct {
+		name       string
+		files      map[string]string
+		wantNames  []string
+		wantErrors []string
+	}{
+		{
+			name: "dependencies.yaml",
+			files: map[string]string{
+				"Chart.yaml":        chartYAML,
+				"dependencies.yaml": "dependencies:\n  - name: redis\n    version: ^17.0.0\n",
+			},
+			wantNames: []string{"redis"},
+		},
+		{
+			name:      "no requirements",
+			files:     map[string]string{"Chart.yaml": chartYAML},
+			wantNames: []string{},
+		},
+		{
+			name: "annotation only",
+			files: map[string]string{
+				"Chart.yaml": annotatedChartYAML("dependencies:\n  - name: redis\n    version: ^17.0.0\n"),
+			},
+			wantNames: []string{"redis"},
+		},
+		{
+			name: "annotation merged after dependencies.yaml",
+			files: map[string]string{
+				"Chart.yaml":        annotatedChartYAML("dependencies:\n  - name: redis\n    version: ^17.0.0\n"),
+				"dependencies.yaml": "dependencies:\n  - name: postgresql\n    version: ^15.0.0\n",
+			},
+			wantNames: []string{"postgresql", "redis"},
+		},
+		{
+			name: "invalid annotation",
+			files: map[string]string{
+				"Chart.yaml": annotatedChartYAML("dependencies:\n  - name: redis\n"),
+			},
+			wantErrors: []string{"Chart.yaml (annotation helm-depcheck/dependencies)"},
+		},
+		{
+			name: "field position",
+			files: map[string]string{
+				"Chart.yaml":        chartYAML,
+				"dependencies.yaml": "dependencies:\n  - name: redis\n    version: ^17.0.0\n  - name: postgresql\n    version: '>> 15'\n",
+			},
+			wantErrors: []string{"dependencies.yaml, line 5, column 14)"},
+		},
+		{
+			name: "annotation position in Chart.yaml",
+			files: map[string]string{
+				"Chart.yaml": annotatedChartYAML("dependencies:\n  - name: redis\n"),
+			},
+			wantErrors: []string{"(line 7, column 9): dependency version cannot be empty"},
+		},
+		{
+			name: "syntax error line",
+			files: map[string]string{
+				"Chart.yaml":        chartYAML,
+				"dependencies.yaml": "dependencies:\n  - name: redis\n    version: ^17.0.0: x\n",
+			},
+			wantErrors: []string{"(line 3): failed to parse YAML"},
+		},
+		{
+			name: "every problem reported at once",
+			files: map[string]string{
+				"Chart.yaml": chartYAML,
+				"dependencies.yaml": `dependencies:
+  - name: ""
+    version: ^1.0.0
+  - name: redis
+    version: ""
+  - name: redis
+    version: ^17.0.0
+  - name: postgresql
+    version: '>> 15'
+`,
+			},
+			wantErrors: []string{
+				"(line 2, column 11): dependency name cannot be empty",
+				"(line 5, column 14): dependency version cannot be empty",
+				"(line 6, column 11): duplicate dependency name",
+				"Invalid version constraint: invalid version constraint '>> 15'",
+			},
+		},
+		{
+			name: "unknown fields",
+			files: map[string]string{
+				"Chart.yaml":        chartYAML,
+				"dependencies.yaml": "dependencies:\n  - name: redis\n    verison: ^17.0.0\n    flavour: cluster\n",
+			},
+			wantErrors: []string{
+				"(line 2, column 5): dependency version cannot be empty",
+				"(line 3, column 5): unknown field 'verison' (did you mean 'version'?)",
+				"(line 4, column 5): unknown field 'flavour'",
+			},
+		},
+		{
+			name: "namespace selectors",
+			files: map[string]string{
+				"Chart.yaml": chartYAML,
+				"dependencies.yaml": `dependencies:
+  - name: redis
+    version: ^17.0.0
+    namespace: cache
+    namespacePattern: ^cache-
+  - name: postgresql
+    version: ^15.0.0
+    namespacePattern: "["
+`,
+			},
+			wantErrors: []string{
+				"(line 5, column 23): namespace and namespacePattern are mutually exclusive",
+				"(line 8, column 23): invalid namespacePattern '['",
+			},
+		},
+		{
+			name: "release name selectors",
+			files: map[string]string{
+				"Chart.yaml": chartYAML,
+				"dependencies.yaml": `dependencies:
+  - name: redis
+    version: ^17.0.0
+    releaseName: cache
+    releaseNamePattern: ^cache-
+  - name: postgresql
+    version: ^15.0.0
+    releaseNamePattern: "("
+`,
+			},
+			wantErrors: []string{
+				"(line 5, column 25): releaseName and releaseNamePattern are mutually exclusive",
+				"(line 8, column 25): invalid releaseNamePattern '('",
+			},
+		},
+		{
+			name: "scopes",
+			files: map[string]string{
+				"Chart.yaml": chartYAML,
+				"dependencies.yaml": `dependencies:
+  - name: redis
+    version: ^17.0.0
+    scope: namespaces
+  - name: postgresql
+    version: ^15.0.0
+    scope: cluster
+    namespace: data
+  - name: valkey
+    version: ^8.0.0
+    scope: everywhere
+`,
+			},
+			wantErrors: []string{
+				"(line 4, column 12): scope namespaces requires namespace or namespacePattern",
+				"(line 7, column 12): namespace and namespacePattern cannot be used with scope cluster",
+				"(line 11, column 12): invalid scope 'everywhere'",
+			},
+		},
+		{
+			name: "anyOf group",
+			files: map[string]string{
+				"Chart.yaml": chartYAML,
+				"dependencies.yaml": `dependencies:
+  - anyOf:
+      - name: redis
+        version: ^17.0.0
+      - name: valkey
+        version: ^8.0.0
+  - name: database
+    anyOf:
+      - name: postgresql
+        version: ^15.0.0
+      - name: mysql
+        version: ^8.0.0
+`,
+			},
+			wantNames: []string{"anyOf(redis|valkey)", "database"},
+		},
+		{
+			name: "invalid anyOf groups",
+			files: map[string]string{
+				"Chart.yaml": chartYAML,
+				"dependencies.yaml": `dependencies:
+  - name: cache
+    version: ^17.0.0
+    anyOf:
+      - name: redis
+        version: ^17.0.0
+      - name: valkey
+        version: ^8.0.0
+        optional: true
+  - name: database
+    anyOf:
+      - name: postgresql
+        version: ^15.0.0
+`,
+			},
+			wantErrors: []string{
+				"(line 3, column 14): anyOf group can only set name, optional and severity",
+				"(line 9, column 19): alternative 'valkey' cannot set anyOf, optional or severity",
+				"(line 12, column 7): anyOf group must list at least two alternatives",
+			},
+		},
+		{
+			name: "conflicts",
+			files: map[string]string{
+				"Chart.yaml": chartYAML,
+				"dependencies.yaml": `conflicts:
+  - name: legacy-auth
+  - name: ""
+    version: ^1.0.0
+  - name: memcached
+    optional: true
+  - name: old-redis
+    appVersion: ">> 6"
+`,
+			},
+			wantErrors: []string{
+				"(line 3, column 11): conflict name cannot be empty",
+				"(line 6, column 15): conflicts cannot set anyOf or optional",
+				"Invalid version constraint: invalid version constraint '>> 6'",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deps, err := NewParser().ParseDependencies(writeChart(t, tt.files))
+			if tt.wantErrors != nil {
+				checkErrors(t, err, tt.wantErrors)
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseDependencies() error = %v", err)
+			}
+
+			if got := dependencyNames(deps); !reflect.DeepEqual(got, tt.wantNames) {
+				t.Errorf("dependencies = %v, want %v", got, tt.wantNames)
+			}
+		})
+	}
+}
+
+func TestParseDependenciesAnnotationSource(t *testing.T) {
+	dir := writeChart(t, map[string]string{
+		"Chart.yaml": annotatedChartYAML("dependencies:\n  - name: redis\n    version: ^17.0.0\n"),
+	})
+
+	deps, err := NewParser().ParseDependencies(dir)
+	if err != nil {
+		t.Fatalf("ParseDependencies() error = %v", err)
+	}
+
+	want := filepath.Join(dir, "Chart.yaml") + " (annotation helm-depcheck/dependencies)"
+	if source := deps.Dependencies[0].Source; source != want {
+		t.Errorf("source = %q, want %q", source, want)
+	}
+}
+
+func TestParseDependenciesDuplicateAcrossSources(t *testing.T) {
+	dir := writeChart(t, map[string]string{
+		"Chart.yaml": `apiVersion: v2
+name: app
+version: 1.0.0
+annotations:
+  helm-depcheck/dependencies: |
+    dependencies:
+      - name: redis
+        version: ^18.0.0
+`,
+		"dependencies.yaml": "dependencies:\n  - name: postgresql\n    version: ^15.0.0\n  - name: redis\n    version: ^17.0.0\n",
+	})
+
+	_, err := NewParser().ParseDependencies(dir)
+	if err == nil {
+		t.Fatal("ParseDependencies() error = nil, want a duplicate dependency error")
+	}
+
+	want := "Invalid dependencies file: " + filepath.Join(dir, "Chart.yaml") + " (annotation helm-depcheck/dependencies) (line 7, column 15): " +
+		"duplicate dependency name (already declared in " + filepath.Join(dir, "dependencies.yaml") + ", line 4)"
+	if err.Error() != want {
+		t.Errorf("ParseDependencies() error = %q, want %q", err.Error(), want)
+	}
+}
+
+// chartArchive packages the given chart files below a top-level app directory, the way
+// helm package does
+func chartArchive(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	gzipWriter := gzip.NewWriter(&buf)
+	tarWriter := tar.NewWriter(gzipWriter)
+	for name, content := range files {
+		header := &tar.Header{Name: "app/" + name, Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg}
+		if err := tarWriter.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tarWriter.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tarWriter.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gzipWriter.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// writeOCILayout creates an OCI image layout holding the chart archive under the given tag
+func writeOCILayout(t *testing.T, archive []byte, tag string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	writeBlob := func(data []byte) string {
+		sum := sha256.Sum256(data)
+		encoded := hex.EncodeToString(sum[:])
+		path := filepath.Join(dir, "blobs", "sha256", encoded)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return "sha256:" + encoded
+	}
+
+	manifest := fmt.Sprintf(`{"layers": [{"mediaType": %q, "digest": %q}]}`, chartLayerMediaType, writeBlob(archive))
+	index := fmt.Sprintf(`{"manifests": [{"mediaType": "application/vnd.oci.image.manifest.v1+json", "digest": %q, "annotations": {%q: %q}}]}`,
+		writeBlob([]byte(manifest)), refNameAnnotation, tag)
+	if err := os.WriteFile(filepath.Join(dir, "index.json"), []byte(index), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestParseDependenciesPackaged(t *testing.T) {
+	archive := chartArchive(t, map[string]string{
+		"Chart.yaml":        chartYAML,
+		"dependencies.yaml": "dependencies:\n  - name: redis\n    version: ^17.0.0\n",
+	})
+	archivePath := filepath.Join(t.TempDir(), "app-1.0.0.tgz")
+	if err := os.WriteFile(archivePath, archive, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		chartRef   string
+		setup      func(p *Parser)
+		wantSource string
+		wantError  string
+	}{
+		{
+			name:       "chart archive",
+			chartRef:   archivePath,
+			wantSource: archivePath + ":dependencies.yaml",
+		},
+		{
+			name:     "oci layout",
+			chartRef: "oci://registry.example.com/charts/app:1.0.0",
+			setup: func(p *Parser) {
+				p.SetOCILayout(writeOCILayout(t, archive, "1.0.0"))
+			},
+			wantSource: "oci://registry.example.com/charts/app:1.0.0:dependencies.yaml",
+		},
+		{
+			name:     "oci tag missing from layout",
+			chartRef: "oci://registry.example.com/charts/app:2.0.0",
+			setup: func(p *Parser) {
+				p.SetOCILayout(writeOCILayout(t, archive, "1.0.0"))
+			},
+			wantError: "reference oci://registry.example.com/charts/app:2.0.0 not found in OCI layout",
+		},
+		{
+			name:     "oci pull",
+			chartRef: "oci://registry.example.com/charts/app:1.0.0",
+			setup: func(p *Parser) {
+				p.SetPullFunc(func(chartRef string) ([]byte, error) { return archive, nil })
+			},
+			wantSource: "oci://registry.example.com/charts/app:1.0.0:dependencies.yaml",
+		},
+		{
+			name:      "oci without registry client",
+			chartRef:  "oci://registry.example.com/charts/app:1.0.0",
+			wantError: "no registry client configured",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser()
+			if tt.setup != nil {
+				tt.setup(p)
+			}
+
+			deps, err := p.ParseDependencies(tt.chartRef)
+			if tt.wantError != "" {
+				checkErrors(t, err, []string{tt.wantError})
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseDependencies() error = %v", err)
+			}
+
+			if got := dependencyNames(deps); !reflect.DeepEqual(got, []string{"redis"}) {
+				t.Errorf("dependencies = %v, want [redis]", got)
+			}
+			if source := deps.Dependencies[0].Source; source != tt.wantSource {
+				t.Errorf("source = %q, want %q", source, tt.wantSource)
+			}
+		})
+	}
+}
+
+func TestParseDependenciesRecursive(t *testing.T) {
+	tests := []struct {
+		name         string
+		files        map[string]string
+		wantVersions map[string]string
+		wantCharts   map[string][]string
+		wantErrors   []string
+	}{
+		{
+			name: "requirements merged across subcharts",
+			files: map[string]string{
+				"Chart.yaml":                     chartYAML,
+				"dependencies.yaml":              "dependencies:\n  - name: redis\n    version: ^17.0.0\n",
+				"charts/cache/Chart.yaml":        "apiVersion: v2\nname: cache\nversion: 1.0.0\n",
+				"charts/cache/dependencies.yaml": "dependencies:\n  - name: redis\n    version: '>=17.2.0'\n  - name: postgresql\n    version: ^15.0.0\n",
+			},
+			wantVersions: map[string]string{"redis": "^17.0.0, >=17.2.0", "postgresql": "^15.0.0"},
+			wantCharts:   map[string][]string{"redis": {"app", "app/cache"}, "postgresql": {"app/cache"}},
+		},
+		{
+			name: "packaged subchart",
+			files: map[string]string{
+				"Chart.yaml":        chartYAML,
+				"dependencies.yaml": "dependencies:\n  - name: redis\n    version: ^17.0.0\n",
+				"charts/db-1.0.0.tgz": string(chartArchive(t, map[string]string{
+					"Chart.yaml":        "apiVersion: v2\nname: db\nversion: 1.0.0\n",
+					"dependencies.yaml": "dependencies:\n  - name: redis\n    version: ^17.0.0\n",
+				})),
+			},
+			wantVersions: map[string]string{"redis": "^17.0.0"},
+			wantCharts:   map[string][]string{"redis": {"app", "app/db"}},
+		},
+		{
+			name: "different selectors",
+			files: map[string]string{
+				"Chart.yaml":                     chartYAML,
+				"dependencies.yaml":              "dependencies:\n  - name: redis\n    version: ^17.0.0\n",
+				"charts/cache/Chart.yaml":        "apiVersion: v2\nname: cache\nversion: 1.0.0\n",
+				"charts/cache/dependencies.yaml": "dependencies:\n  - name: redis\n    version: ^17.0.0\n    namespace: cache\n",
+			},
+			wantErrors: []string{"(line 2, column 11): declared with different selectors or alternatives than in app"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deps, err := NewParser().ParseDependenciesRecursive(writeChart(t, tt.files))
+			if tt.wantErrors != nil {
+				checkErrors(t, err, tt.wantErrors)
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseDependenciesRecursive() error = %v", err)
+			}
+
+			if len(deps.Dependencies) != len(tt.wantVersions) {
+				t.Fatalf("dependencies = %v, want %d", dependencyNames(deps), len(tt.wantVersions))
+			}
+			for _, dep := range deps.Dependencies {
+				if want := tt.wantVersions[dep.Name]; dep.Version != want {
+					t.Errorf("%s version = %q, want %q", dep.Name, dep.Version, want)
+				}
+				var charts []string
+				for _, declaration := range dep.DeclaredBy {
+					charts = append(charts, declaration.Chart)
+				}
+				if want := tt.wantCharts[dep.Name]; !reflect.DeepEqual(charts, want) {
+					t.Errorf("%s declared by %v, want %v", dep.Name, charts, want)
+				}
+			}
+		})
+	}
+}
+
+func TestSchemaKnowsEveryField(t *testing.T) {
+	known := make(map[string]bool)
+	for _, field := range knownFields() {
+		known[field] = true
+	}
+
+	for _, value := range []any{types.DependenciesFile{}, types.Dependency{}, types.Conflict{}} {
+		structType := reflect.TypeOf(value)
+		for i := 0; i < structType.NumField(); i++ {
+			name, _, _ := strings.Cut(structType.Field(i).Tag.Get("yaml"), ",")
+			if name == "" || name == "-" {
+				continue
+			}
+			if !known[name] {
+				t.Errorf("field %s of %s is missing from the schema", name, structType.Name())
+			}
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"helm-depcheck/pkg/types"
+)
+
+// yamlErrorLinePattern matches the line reported in YAML syntax and type errors
+var yamlErrorLinePattern = regexp.MustCompile(`line (\d+)`)
+
+// unknownFieldPattern matches the errors reported by the YAML decoder for unknown fields
+var unknownFieldPattern = regexp.MustCompile(`field (\S+) not found in type`)
+
+// fieldDetails returns error details locating a field of a YAML mapping node. The value of
+// the field is reported when it is present and the mapping itself otherwise.
+func fieldDetails(filePath string, node *yaml.Node, field string) types.ErrorDetails {
+	details := types.ErrorDetails{File: filePath}
+	if node == nil {
+		return details
+	}
+
+	target := node
+	if _, value := mappingField(node, field); value != nil {
+		target = value
+	}
+
+	details.Line = target.Line
+	details.Column = target.Column
+	return details
+}
+
+// locate records the source of a parsed requirement and the position of its name, or of
+// the entry when it has none
+func locate(dep *types.Dependency, source string, node *yaml.Node) {
+	details := fieldDetails(source, node, "name")
+	dep.Source = source
+	dep.Line = details.Line
+	dep.Column = details.Column
+}
+
+// declarationDetails returns error details locating the declaration of a requirement
+func declarationDetails(dep types.Dependency) types.ErrorDetails {
+	return types.ErrorDetails{File: dep.Source, Line: dep.Line, Column: dep.Column}
+}
+
+// declarationPosition describes where a requirement is declared, e.g.
+// "chart/dependencies.yaml, line 3"
+func declarationPosition(dep types.Dependency) string {
+	if dep.Line == 0 {
+		return dep.Source
+	}
+	return fmt.Sprintf("%s, line %d", dep.Source, dep.Line)
+}
+
+// mappingField returns the key and value nodes of a field of a YAML mapping node
+func mappingField(node *yaml.Node, field string) (*yaml.Node, *yaml.Node) {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil, nil
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == field {
+			return node.Content[i], node.Content[i+1]
+		}
+	}
+
+	return nil, nil
+}
+
+// sequenceItems returns the items of the sequence held by a field of a YAML mapping node
+func sequenceItems(node *yaml.Node, field string) []*yaml.Node {
+	_, value := mappingField(node, field)
+	if value == nil || value.Kind != yaml.SequenceNode {
+		return nil
+	}
+	return value.Content
+}
+
+// findKey returns the mapping key with the given name on the given line, searching the
+// whole YAML tree
+func findKey(node *yaml.Node, name string, line int) *yaml.Node {
+	if node.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if key := node.Content[i]; key.Value == name && key.Line == line {
+				return key
+			}
+		}
+	}
+
+	for _, child := range node.Content {
+		if key := findKey(child, name, line); key != nil {
+			return key
+		}
+	}
+
+	return nil
+}
+
+// unknownField returns the field named by an unknown field error of the YAML decoder
+func unknownField(message string) (string, bool) {
+	match := unknownFieldPattern.FindStringSubmatch(message)
+	if match == nil {
+		return "", false
+	}
+	return match[1], true
+}
+
+// nodeAt returns the node at the given index, or nil when there is none
+func nodeAt(nodes []*yaml.Node, i int) *yaml.Node {
+	if i < 0 || i >= len(nodes) {
+		return nil
+	}
+	return nodes[i]
+}
+
+// shiftPositions moves the positions of all nodes of a YAML tree by the given offsets
+func shiftPositions(node *yaml.Node, lineOffset, columnOffset int) {
+	if lineOffset == 0 && columnOffset == 0 {
+		return
+	}
+
+	node.Line += lineOffset
+	node.Column += columnOffset
+	for _, child := range node.Content {
+		shiftPositions(child, lineOffset, columnOffset)
+	}
+}
+
+// annotationOffset returns the line and column offsets of the dependencies annotation
+// within Chart.yaml. Only literal block scalars keep the layout of the embedded YAML, so
+// other styles yield no offsets and positions relative to the annotation value.
+func annotationOffset(data []byte, root *yaml.Node) (int, int) {
+	if len(root.Content) == 0 {
+		return 0, 0
+	}
+
+	_, annotations := mappingField(root.Content[0], "annotations")
+	_, value := mappingField(annotations, DependenciesAnnotation)
+	if value == nil || value.Style&yaml.LiteralStyle == 0 {
+		return 0, 0
+	}
+
+	// The content starts on the line after the block indicator and every line carries the
+	// indentation of the first non-empty one
+	lines := strings.Split(string(data), "\n")
+	for i := value.Line; i < len(lines); i++ {
+		line := strings.TrimRight(lines[i], "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		return value.Line, len(line) - len(strings.TrimLeft(line, " "))
+	}
+
+	return 0, 0
+}
+
+// extractLineFromYAMLError extracts the line number from YAML parsing errors
+func extractLineFromYAMLError(err error) int {
+	match := yamlErrorLinePattern.FindStringSubmatch(err.Error())
+	if match == nil {
+		return 0 // Unknown line
+	}
+
+	line, convErr := strconv.Atoi(match[1])
+	if convErr != nil {
+		return 0
+	}
+	return line
+}
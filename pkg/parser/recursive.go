@@ -0,0 +1,223 @@
+package parser
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"helm-depcheck/pkg/types"
+)
+
+// ParseDependenciesRecursive reads the dependency requirements of the given chart and of all
+// subcharts vendored in its charts/ directory, at any depth and whether unpacked or
+// packaged. A dependency declared by several charts is merged into a single requirement
+// whose constraints must all hold, and every dependency records the charts that declared it.
+func (p *Parser) ParseDependenciesRecursive(chartPath string) (*types.DependenciesFile, error) {
+	source, err := p.loadChart(chartPath)
+	if err != nil {
+		return nil, types.NewValidationError(
+			types.ErrorTypeInvalidDependencyFile,
+			"",
+			err.Error(),
+			types.ErrorDetails{File: chartPath},
+		)
+	}
+
+	merged := &types.DependenciesFile{Dependencies: []types.Dependency{}}
+	if errs := p.collectDependencies(source, "", merged, make(map[string]int)); len(errs) > 0 {
+		return nil, errs
+	}
+
+	return merged, nil
+}
+
+// collectDependencies adds the requirements of the chart and its subcharts to merged and
+// returns the problems found in any of them. The index maps dependency names to their
+// position in merged.Dependencies.
+func (p *Parser) collectDependencies(source *chartSource, parent string, merged *types.DependenciesFile, index map[string]int) types.ValidationErrors {
+	var errs types.ValidationErrors
+	chart := p.chartLabel(source, parent)
+
+	deps, err := p.parseChartDependencies(source)
+	if err != nil {
+		errs = errs.Append(err)
+		deps = &types.DependenciesFile{}
+	}
+
+	for _, dep := range deps.Dependencies {
+		dep.DeclaredBy = []types.Declaration{{
+			Chart:      chart,
+			Version:    dep.Version,
+			AppVersion: dep.AppVersion,
+			Source:     dep.Source,
+		}}
+
+		name := dep.DisplayName()
+		i, ok := index[name]
+		if !ok {
+			index[name] = len(merged.Dependencies)
+			merged.Dependencies = append(merged.Dependencies, dep)
+			continue
+		}
+
+		combined, err := mergeRequirement(merged.Dependencies[i], dep)
+		if err != nil {
+			errs = errs.Append(err)
+			continue
+		}
+		merged.Dependencies[i] = combined
+	}
+
+	for _, conflict := range deps.Conflicts {
+		conflict.DeclaredBy = []types.Declaration{{
+			Chart:      chart,
+			Version:    conflict.Version,
+			AppVersion: conflict.AppVersion,
+			Source:     conflict.Source,
+		}}
+		merged.Conflicts = append(merged.Conflicts, conflict)
+	}
+
+	subcharts, err := source.subcharts()
+	if err != nil {
+		return append(errs, types.NewValidationError(
+			types.ErrorTypeInvalidDependencyFile,
+			"",
+			err.Error(),
+			types.ErrorDetails{File: source.path("charts")},
+		))
+	}
+
+	for _, subchart := range subcharts {
+		errs = append(errs, p.collectDependencies(subchart, chart, merged, index)...)
+	}
+
+	return errs
+}
+
+// chartLabel returns the label identifying a chart in the subchart tree, e.g.
+// "platform/redis-cache". Charts whose name cannot be read are labelled by their path.
+func (p *Parser) chartLabel(source *chartSource, parent string) string {
+	name := path.Base(source.dir)
+	if source.dir == "" {
+		name = filepath.Base(source.ref)
+	}
+
+	if data, err := source.readFile("Chart.yaml"); err == nil {
+		var chart struct {
+			Name string `yaml:"name"`
+		}
+		if err := yaml.Unmarshal(data, &chart); err == nil && chart.Name != "" {
+			name = chart.Name
+		}
+	}
+
+	if parent == "" {
+		return name
+	}
+	return parent + "/" + name
+}
+
+// mergeRequirement combines two declarations of the same dependency by different charts.
+// Both must select the same releases. The merged version constraints hold only when those
+// of both declarations do, and the merged dependency is only soft when both are.
+func mergeRequirement(existing, dep types.Dependency) (types.Dependency, error) {
+	name := dep.DisplayName()
+	previous := existing.DeclaredBy[0].Chart
+
+	if existing.IsGroup() != dep.IsGroup() {
+		return existing, types.NewValidationError(
+			types.ErrorTypeInvalidDependencyFile,
+			name,
+			fmt.Sprintf("declared both as an anyOf group and as a single dependency (see %s)", previous),
+			declarationDetails(dep),
+		)
+	}
+
+	if !sameSelectors(existing, dep) || !sameAlternatives(existing, dep) {
+		return existing, types.NewValidationError(
+			types.ErrorTypeInvalidDependencyFile,
+			name,
+			fmt.Sprintf("declared with different selectors or alternatives than in %s", previous),
+			declarationDetails(dep),
+		)
+	}
+
+	merged := existing
+	merged.Version = intersectConstraints(existing.Version, dep.Version)
+	merged.AppVersion = intersectConstraints(existing.AppVersion, dep.AppVersion)
+	merged.DeclaredBy = append(append([]types.Declaration{}, existing.DeclaredBy...), dep.DeclaredBy...)
+
+	if existing.IsSoft() && dep.IsSoft() {
+		merged.Optional = existing.Optional && dep.Optional
+		if !merged.Optional {
+			merged.Severity = types.SeverityWarning
+		}
+	} else {
+		merged.Optional = false
+		if merged.Severity == types.SeverityWarning {
+			merged.Severity = types.SeverityError
+		}
+	}
+
+	return merged, nil
+}
+
+// sameSelectors reports whether both dependencies select releases the same way
+func sameSelectors(a, b types.Dependency) bool {
+	return a.Name == b.Name &&
+		a.Namespace == b.Namespace &&
+		a.NamespacePattern == b.NamespacePattern &&
+		a.ReleaseName == b.ReleaseName &&
+		a.ReleaseNamePattern == b.ReleaseNamePattern &&
+		a.EffectiveScope() == b.EffectiveScope()
+}
+
+// sameAlternatives reports whether two anyOf groups list the same alternatives in the same
+// order. Dependencies that are not groups have no alternatives and always match.
+func sameAlternatives(a, b types.Dependency) bool {
+	if len(a.AnyOf) != len(b.AnyOf) {
+		return false
+	}
+
+	for i := range a.AnyOf {
+		if !sameSelectors(a.AnyOf[i], b.AnyOf[i]) ||
+			a.AnyOf[i].Version != b.AnyOf[i].Version ||
+			a.AnyOf[i].AppVersion != b.AnyOf[i].AppVersion {
+			return false
+		}
+	}
+
+	return true
+}
+
+// intersectConstraints combines two version constraints into one that holds only when both
+// do. Constraints are alternatives separated by "||", so the result lists every pairing of
+// an alternative of a with one of b, e.g. "^1.0 || ^2.0" and ">=1.5" become
+// "^1.0, >=1.5 || ^2.0, >=1.5". An empty constraint places no restriction.
+func intersectConstraints(a, b string) string {
+	a, b = strings.TrimSpace(a), strings.TrimSpace(b)
+	if a == "" || a == b {
+		return b
+	}
+	if b == "" {
+		return a
+	}
+
+	var combined []string
+	for _, left := range strings.Split(a, "||") {
+		for _, right := range strings.Split(b, "||") {
+			left, right = strings.TrimSpace(left), strings.TrimSpace(right)
+			if left == right {
+				combined = append(combined, left)
+			} else {
+				combined = append(combined, left+", "+right)
+			}
+		}
+	}
+
+	return strings.Join(combined, " || ")
+}
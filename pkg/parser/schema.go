@@ -0,0 +1,101 @@
+package parser
+
+import (
+	_ "embed"
+	"encoding/json"
+	"sort"
+	"sync"
+)
+
+// dependenciesSchema is the JSON Schema of dependencies.yaml
+//
+//go:embed dependencies.schema.json
+var dependenciesSchema []byte
+
+// Schema returns the JSON Schema describing dependencies.yaml and the dependencies
+// annotation, for editors and CI tools to validate the file with
+func Schema() []byte {
+	return dependenciesSchema
+}
+
+var (
+	schemaFieldsOnce sync.Once
+	schemaFields     []string
+)
+
+// knownFields returns the names of all fields defined by the schema, sorted
+func knownFields() []string {
+	schemaFieldsOnce.Do(func() {
+		var schema any
+		if err := json.Unmarshal(dependenciesSchema, &schema); err != nil {
+			return
+		}
+
+		fields := make(map[string]bool)
+		collectSchemaFields(schema, fields)
+		for field := range fields {
+			schemaFields = append(schemaFields, field)
+		}
+		sort.Strings(schemaFields)
+	})
+	return schemaFields
+}
+
+// collectSchemaFields adds the keys of every "properties" object of the schema to fields
+func collectSchemaFields(node any, fields map[string]bool) {
+	switch node := node.(type) {
+	case map[string]any:
+		if properties, ok := node["properties"].(map[string]any); ok {
+			for field := range properties {
+				fields[field] = true
+			}
+		}
+		for _, child := range node {
+			collectSchemaFields(child, fields)
+		}
+	case []any:
+		for _, child := range node {
+			collectSchemaFields(child, fields)
+		}
+	}
+}
+
+// suggestField returns the known field closest to an unknown one, or an empty string when
+// no field is close enough to be a likely typo
+func suggestField(unknown string) string {
+	best, bestDistance := "", 3
+	for _, field := range knownFields() {
+		if distance := editDistance(unknown, field); distance < bestDistance {
+			best, bestDistance = field, distance
+		}
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between two strings, counting a swap of
+// adjacent characters as a single edit
+func editDistance(a, b string) int {
+	previous2 := make([]int, len(b)+1)
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				current[j] = min(current[j], previous2[j-2]+1)
+			}
+		}
+		previous2, previous, current = previous, current, previous2
+	}
+
+	return previous[len(b)]
+}
@@ -0,0 +1,157 @@
+package parser
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// PullFunc pulls the gzipped chart archive of an OCI reference from a registry
+type PullFunc func(chartRef string) ([]byte, error)
+
+// chartSource holds the files of a chart loaded from a directory, a packaged chart
+// archive or an OCI reference. Subcharts unpacked inside an archive share its reference
+// and are located by their directory within it.
+type chartSource struct {
+	ref   string
+	dir   string
+	files fs.FS
+	isDir bool
+}
+
+// path returns the display path of a file of the chart
+func (s *chartSource) path(name string) string {
+	if s.isDir {
+		return filepath.Join(s.ref, filepath.FromSlash(name))
+	}
+	return s.ref + ":" + path.Join(s.dir, name)
+}
+
+// readFile reads a file of the chart
+func (s *chartSource) readFile(name string) ([]byte, error) {
+	return fs.ReadFile(s.files, name)
+}
+
+// subcharts returns the charts vendored in the charts/ directory of the chart, both
+// unpacked directories containing a Chart.yaml and packaged chart archives
+func (s *chartSource) subcharts() ([]*chartSource, error) {
+	entries, err := fs.ReadDir(s.files, "charts")
+	if err != nil {
+		if isNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read %s: %v", s.path("charts"), err)
+	}
+
+	var subcharts []*chartSource
+	for _, entry := range entries {
+		name := path.Join("charts", entry.Name())
+
+		if entry.IsDir() {
+			files, err := fs.Sub(s.files, name)
+			if err != nil {
+				return nil, fmt.Errorf("failed to open subchart %s: %v", s.path(name), err)
+			}
+			if _, err := fs.Stat(files, "Chart.yaml"); err != nil {
+				continue
+			}
+
+			subchart := &chartSource{ref: s.ref, dir: path.Join(s.dir, name), files: files, isDir: s.isDir}
+			if s.isDir {
+				subchart.ref = filepath.Join(s.ref, filepath.FromSlash(name))
+				subchart.dir = ""
+			}
+			subcharts = append(subcharts, subchart)
+			continue
+		}
+
+		if !isArchivePath(entry.Name()) {
+			continue
+		}
+
+		data, err := fs.ReadFile(s.files, name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read subchart archive %s: %v", s.path(name), err)
+		}
+		files, err := loadArchive(data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid subchart archive %s: %v", s.path(name), err)
+		}
+		subcharts = append(subcharts, &chartSource{ref: s.path(name), files: files})
+	}
+
+	return subcharts, nil
+}
+
+// isArchivePath reports whether the file name is that of a packaged chart archive
+func isArchivePath(name string) bool {
+	return strings.HasSuffix(name, ".tgz") || strings.HasSuffix(name, ".tar.gz")
+}
+
+// loadChart opens the chart at the given reference. Loaded charts are cached so archives
+// and OCI artifacts are only read or pulled once.
+func (p *Parser) loadChart(chartRef string) (*chartSource, error) {
+	if source, ok := p.charts[chartRef]; ok {
+		return source, nil
+	}
+
+	var source *chartSource
+	if IsOCIReference(chartRef) {
+		data, err := p.pullChart(chartRef)
+		if err != nil {
+			return nil, err
+		}
+		files, err := loadArchive(data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid chart '%s': %v", chartRef, err)
+		}
+		source = &chartSource{ref: chartRef, files: files}
+	} else {
+		info, err := os.Stat(chartRef)
+		if err != nil {
+			return nil, fmt.Errorf("invalid chart path '%s': %v", chartRef, err)
+		}
+
+		if info.IsDir() {
+			source = &chartSource{ref: chartRef, files: os.DirFS(chartRef), isDir: true}
+		} else {
+			data, err := os.ReadFile(chartRef)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read chart archive '%s': %v", chartRef, err)
+			}
+			files, err := loadArchive(data)
+			if err != nil {
+				return nil, fmt.Errorf("invalid chart archive '%s': %v", chartRef, err)
+			}
+			source = &chartSource{ref: chartRef, files: files}
+		}
+	}
+
+	p.charts[chartRef] = source
+	return source, nil
+}
+
+// pullChart reads the chart archive of an OCI reference from the local OCI layout if one is
+// configured, and from the registry otherwise
+func (p *Parser) pullChart(chartRef string) ([]byte, error) {
+	if p.ociLayout != "" {
+		data, err := readOCILayout(p.ociLayout, chartRef)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s from OCI layout %s: %v", chartRef, p.ociLayout, err)
+		}
+		return data, nil
+	}
+
+	if p.pull == nil {
+		return nil, fmt.Errorf("cannot pull %s: no registry client configured", chartRef)
+	}
+
+	data, err := p.pull(chartRef)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pull %s: %v", chartRef, err)
+	}
+	return data, nil
+}
@@ -2,18 +2,99 @@ package types
 
 import (
 	"fmt"
+	"regexp"
+	"sort"
+	"strings"
 	"time"
 )
 
 // Dependency represents a single dependency constraint from dependencies.yaml
 type Dependency struct {
-	Name    string `yaml:"name" json:"name"`
-	Version string `yaml:"version" json:"version"`
+	Name               string        `yaml:"name" json:"name"`
+	Version            string        `yaml:"version" json:"version"`
+	Namespace          string        `yaml:"namespace,omitempty" json:"namespace,omitempty"`
+	NamespacePattern   string        `yaml:"namespacePattern,omitempty" json:"namespacePattern,omitempty"`
+	ReleaseName        string        `yaml:"releaseName,omitempty" json:"releaseName,omitempty"`
+	ReleaseNamePattern string        `yaml:"releaseNamePattern,omitempty" json:"releaseNamePattern,omitempty"`
+	Scope              string        `yaml:"scope,omitempty" json:"scope,omitempty"`
+	AppVersion         string        `yaml:"appVersion,omitempty" json:"appVersion,omitempty"`
+	Optional           bool          `yaml:"optional,omitempty" json:"optional,omitempty"`
+	Severity           string        `yaml:"severity,omitempty" json:"severity,omitempty"`
+	AnyOf              []Dependency  `yaml:"anyOf,omitempty" json:"anyOf,omitempty"`
+	Source             string        `yaml:"-" json:"source,omitempty"`     // file the requirement was declared in
+	Line               int           `yaml:"-" json:"-"`                    // line of the requirement in its source
+	Column             int           `yaml:"-" json:"-"`                    // column of the requirement in its source
+	DeclaredBy         []Declaration `yaml:"-" json:"declaredBy,omitempty"` // charts declaring the requirement in recursive mode
+}
+
+// Declaration records a chart that declared a dependency and the constraints it declared
+type Declaration struct {
+	Chart      string `json:"chart"`
+	Version    string `json:"version,omitempty"`
+	AppVersion string `json:"app_version,omitempty"`
+	Source     string `json:"source,omitempty"`
+}
+
+// IsGroup reports whether the dependency is an anyOf group of alternatives
+func (d Dependency) IsGroup() bool {
+	return len(d.AnyOf) > 0
+}
+
+// DisplayName returns the name of the dependency. Unnamed anyOf groups are
+// named after their alternatives, e.g. "anyOf(redis|valkey)".
+func (d Dependency) DisplayName() string {
+	if d.Name != "" || !d.IsGroup() {
+		return d.Name
+	}
+
+	names := make([]string, len(d.AnyOf))
+	for i, alternative := range d.AnyOf {
+		names[i] = alternative.Name
+	}
+	return fmt.Sprintf("anyOf(%s)", strings.Join(names, "|"))
+}
+
+// IsSoft reports whether an unsatisfied dependency only produces a warning
+func (d Dependency) IsSoft() bool {
+	return d.Optional || d.Severity == SeverityWarning
+}
+
+// Dependency severity constants
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// EffectiveScope returns the scope of the dependency, defaulting to ScopeNamespaces when
+// namespace constraints are present and to ScopeCluster otherwise
+func (d Dependency) EffectiveScope() string {
+	if d.Scope != "" {
+		return d.Scope
+	}
+	if d.Namespace != "" || d.NamespacePattern != "" {
+		return ScopeNamespaces
+	}
+	return ScopeCluster
+}
+
+// Dependency scope constants
+const (
+	ScopeSameNamespace = "same-namespace"
+	ScopeCluster       = "cluster"
+	ScopeNamespaces    = "namespaces"
+)
+
+// Conflict represents a chart that must not be deployed alongside the chart. It uses the
+// same selectors as a Dependency; an empty version matches any deployed version.
+type Conflict struct {
+	Dependency `yaml:",inline"`
+	Reason     string `yaml:"reason,omitempty" json:"reason,omitempty"`
 }
 
 // DependenciesFile represents the structure of dependencies.yaml
 type DependenciesFile struct {
 	Dependencies []Dependency `yaml:"dependencies" json:"dependencies"`
+	Conflicts    []Conflict   `yaml:"conflicts,omitempty" json:"conflicts,omitempty"`
 }
 
 // Release represents a deployed Helm release
@@ -24,40 +105,161 @@ type Release struct {
 	Status    string
 	Version   int
 	Updated   time.Time
+
+	// Assumed marks releases patched or added by an --assume override
+	Assumed bool `json:",omitempty" yaml:",omitempty"`
+}
+
+// Label identifies the release and its chart, e.g. "shared/redis (redis@17.3.0)"
+func (r Release) Label() string {
+	return fmt.Sprintf("%s/%s (%s@%s)", r.Namespace, r.Name, r.Chart.Name, r.Chart.Version)
+}
+
+// Assumption overrides the chart of a release for a what-if check, e.g.
+// "shared/redis=redis@8.0.0"
+type Assumption struct {
+	Namespace string `json:"namespace"`
+	Release   string `json:"release"`
+	Chart     string `json:"chart"`
+	Version   string `json:"version"`
+}
+
+// ParseAssumption parses an assumption in the form namespace/release=chart@version
+func ParseAssumption(value string) (Assumption, error) {
+	target, chart, found := strings.Cut(value, "=")
+	if !found {
+		return Assumption{}, fmt.Errorf("invalid assumption '%s': must be namespace/release=chart@version", value)
+	}
+
+	namespace, release, found := strings.Cut(target, "/")
+	if !found || namespace == "" || release == "" || strings.Contains(release, "/") {
+		return Assumption{}, fmt.Errorf("invalid assumption '%s': release must be namespace/release", value)
+	}
+
+	name, version, found := strings.Cut(chart, "@")
+	if !found || name == "" || version == "" {
+		return Assumption{}, fmt.Errorf("invalid assumption '%s': chart must be chart@version", value)
+	}
+
+	return Assumption{Namespace: namespace, Release: release, Chart: name, Version: version}, nil
+}
+
+// String formats the assumption as namespace/release=chart@version
+func (a Assumption) String() string {
+	return fmt.Sprintf("%s/%s=%s@%s", a.Namespace, a.Release, a.Chart, a.Version)
+}
+
+// NamespaceAccessError records a namespace whose releases could not be listed
+type NamespaceAccessError struct {
+	Namespace string
+	Message   string
 }
 
 // ChartInfo contains information about a Helm chart
 type ChartInfo struct {
-	Name    string
-	Version string
+	Name       string
+	Version    string
+	AppVersion string
+
+	// Requirements stored with the chart of a deployed release, read in transitive mode
+	DependenciesFile       []byte `json:"-" yaml:"-"`
+	DependenciesAnnotation string `json:"-" yaml:"-"`
 }
 
 // CheckResult represents the result of dependency checking
 type CheckResult struct {
 	Success           bool               `json:"success"`
+	Chart             string             `json:"chart,omitempty"`
+	Assumptions       []Assumption       `json:"assumptions,omitempty"`
 	Dependencies      []DependencyResult `json:"dependencies"`
+	Conflicts         []DependencyResult `json:"conflicts,omitempty"`
+	Transitive        []DependencyResult `json:"transitive,omitempty"`
 	Errors            []ValidationError  `json:"errors,omitempty"`
+	Warnings          []ValidationError  `json:"warnings,omitempty"`
 	Summary           ResultSummary      `json:"summary"`
 	MatchedNamespaces []string           `json:"matched_namespaces,omitempty"`
+	TargetNamespace   string             `json:"target_namespace,omitempty"`
 }
 
 // DependencyResult represents the check result for a single dependency
 type DependencyResult struct {
-	Name            string    `json:"name"`
-	RequiredVersion string    `json:"required_version"`
-	Status          string    `json:"status"` // "satisfied", "not_found", "version_mismatch", "multiple_found"
-	FoundReleases   []Release `json:"found_releases,omitempty"`
-	Error           string    `json:"error,omitempty"`
+	Name               string             `json:"name"`
+	RequiredVersion    string             `json:"required_version"`
+	RequiredAppVersion string             `json:"required_app_version,omitempty"`
+	Status             string             `json:"status"` // "satisfied", "not_found", "version_mismatch", "multiple_found"
+	Scope              string             `json:"scope,omitempty"`
+	Optional           bool               `json:"optional,omitempty"`
+	Severity           string             `json:"severity,omitempty"`
+	Namespace          string             `json:"namespace,omitempty"`
+	NamespacePattern   string             `json:"namespace_pattern,omitempty"`
+	ReleaseName        string             `json:"release_name,omitempty"`
+	ReleaseNamePattern string             `json:"release_name_pattern,omitempty"`
+	FoundReleases      []Release          `json:"found_releases,omitempty"`
+	Error              string             `json:"error,omitempty"`
+	Reason             string             `json:"reason,omitempty"`
+	Source             string             `json:"source,omitempty"`
+	Line               int                `json:"line,omitempty"`
+	Column             int                `json:"column,omitempty"`
+	Chosen             string             `json:"chosen,omitempty"`
+	Alternatives       []DependencyResult `json:"alternatives,omitempty"`
+	DeclaredBy         []Declaration      `json:"declared_by,omitempty"`
+	RequiredBy         string             `json:"required_by,omitempty"`
+	Depth              int                `json:"depth,omitempty"`
+}
+
+// ReverseResult represents the result of a reverse dependency check: how a proposed
+// upgrade or uninstall of a chart's releases affects the releases declaring a dependency
+// on it
+type ReverseResult struct {
+	Success      bool                `json:"success"`
+	Chart        string              `json:"chart"`
+	Release      string              `json:"release,omitempty"`
+	ToVersion    string              `json:"to_version,omitempty"`
+	ToAppVersion string              `json:"to_app_version,omitempty"`
+	Uninstall    bool                `json:"uninstall,omitempty"`
+	Assumptions  []Assumption        `json:"assumptions,omitempty"`
+	Changed      []Release           `json:"changed,omitempty"`
+	Dependents   []ReverseDependency `json:"dependents"`
+	Errors       []ValidationError   `json:"errors,omitempty"`
+	Warnings     []ValidationError   `json:"warnings,omitempty"`
+	Summary      ReverseSummary      `json:"summary"`
+}
+
+// ReverseDependency is a dependency declared by a deployed release on the changed chart,
+// evaluated before and after the change
+type ReverseDependency struct {
+	RequiredBy string           `json:"required_by"`
+	Current    DependencyResult `json:"current"`
+	Proposed   DependencyResult `json:"proposed"`
+	Breaks     bool             `json:"breaks"`
+}
+
+// ReverseSummary provides a summary of the reverse check results
+type ReverseSummary struct {
+	Releases   int `json:"releases"`
+	Dependents int `json:"dependents"`
+	Unaffected int `json:"unaffected"`
+	Broken     int `json:"broken"`
+	Unmet      int `json:"unmet"`
+	Warnings   int `json:"warnings"`
 }
 
 // ResultSummary provides a summary of the check results
 type ResultSummary struct {
-	Total      int `json:"total"`
-	Satisfied  int `json:"satisfied"`
-	NotFound   int `json:"not_found"`
-	Mismatched int `json:"mismatched"`
-	Multiple   int `json:"multiple"`
-	Errors     int `json:"errors"`
+	Total         int `json:"total"`
+	Satisfied     int `json:"satisfied"`
+	NotFound      int `json:"not_found"`
+	Mismatched    int `json:"mismatched"`
+	Multiple      int `json:"multiple"`
+	Errors        int `json:"errors"`
+	NoAlternative int `json:"no_alternative"`
+	Conflicts     int `json:"conflicts"`
+	Contradictory int `json:"contradictory"`
+	Transitive    int `json:"transitive"`
+	BrokenLinks   int `json:"broken_links"`
+	Cycles        int `json:"cycles"`
+	Optional      int `json:"optional"`
+	Warnings      int `json:"warnings"`
 }
 
 // ValidationError represents different types of validation errors
@@ -79,28 +281,62 @@ const (
 	ErrorTypeInvalidDependencyFile    ErrorType = "invalid_dependency_file"
 	ErrorTypeHelmClientError          ErrorType = "helm_client_error"
 	ErrorTypeInvalidVersionConstraint ErrorType = "invalid_version_constraint"
+	ErrorTypeNamespaceUnreadable      ErrorType = "namespace_unreadable"
+	ErrorTypeAppVersionMismatch       ErrorType = "app_version_mismatch"
+	ErrorTypeInvalidAppVersion        ErrorType = "invalid_app_version"
+	ErrorTypeNoAlternativeSatisfied   ErrorType = "no_alternative_satisfied"
+	ErrorTypeConflictDetected         ErrorType = "conflict_detected"
+	ErrorTypeContradictoryConstraints ErrorType = "contradictory_constraints"
+	ErrorTypeDependencyCycle          ErrorType = "dependency_cycle"
+	ErrorTypeNoTargetNamespace        ErrorType = "no_target_namespace"
 )
 
 // ErrorDetails contains additional context for errors
 type ErrorDetails struct {
-	RequiredVersion string   `json:"required_version,omitempty"`
-	FoundVersion    string   `json:"found_version,omitempty"`
-	Namespace       string   `json:"namespace,omitempty"`
-	Release         string   `json:"release,omitempty"`
-	SearchPattern   string   `json:"search_pattern,omitempty"`
-	FoundNamespaces []string `json:"found_namespaces,omitempty"`
-	FoundReleases   []string `json:"found_releases,omitempty"`
-	File            string   `json:"file,omitempty"`
-	Line            int      `json:"line,omitempty"`
+	RequiredVersion    string   `json:"required_version,omitempty"`
+	FoundVersion       string   `json:"found_version,omitempty"`
+	RequiredAppVersion string   `json:"required_app_version,omitempty"`
+	FoundAppVersion    string   `json:"found_app_version,omitempty"`
+	Namespace          string   `json:"namespace,omitempty"`
+	Release            string   `json:"release,omitempty"`
+	SearchPattern      string   `json:"search_pattern,omitempty"`
+	FoundNamespaces    []string `json:"found_namespaces,omitempty"`
+	FoundReleases      []string `json:"found_releases,omitempty"`
+	Alternatives       []string `json:"alternatives,omitempty"`
+	DeclaredBy         []string `json:"declared_by,omitempty"`
+	Cycle              []string `json:"cycle,omitempty"`
+	File               string   `json:"file,omitempty"`
+	RequiredBy         string   `json:"required_by,omitempty"`
+	Hint               string   `json:"hint,omitempty"`
+	Line               int      `json:"line,omitempty"`
+	Column             int      `json:"column,omitempty"`
 }
 
 // Config holds configuration for the dependency checker
 type Config struct {
-	ChartPath        string
-	NamespacePattern string
-	Verbose          bool
-	OutputFormat     string
-	KubeConfig       string
+	ChartPath           string
+	NamespacePattern    string
+	TargetNamespace     string
+	Verbose             bool
+	OutputFormat        string
+	KubeConfig          string
+	ReleasesFixture     string
+	Concurrency         int
+	Timeout             time.Duration
+	StrictAccess        bool
+	StorageDriver       string
+	SQLConnectionString string
+	OCILayout           string
+	Recursive           bool
+	Transitive          bool
+	Assumptions         []Assumption
+
+	// Proposed change evaluated by the reverse check
+	ReverseChart   string
+	ReverseRelease string
+	ToVersion      string
+	ToAppVersion   string
+	Uninstall      bool
 }
 
 // OutputFormat defines supported output formats
@@ -114,13 +350,67 @@ const (
 
 // Error implementations for ValidationError
 func (e ValidationError) Error() string {
+	message := e.message()
+	if e.Details.RequiredBy != "" {
+		message += fmt.Sprintf(" [required by %s]", e.Details.RequiredBy)
+	}
+	return message
+}
+
+// message formats the error according to its type
+func (e ValidationError) message() string {
 	switch e.Type {
 	case ErrorTypeDependencyNotFound:
-		return fmt.Sprintf("Dependency not found: %s (required: %s, search pattern: %s)",
-			e.Chart, e.Details.RequiredVersion, e.Details.SearchPattern)
+		var message string
+		switch {
+		case e.Details.Release != "":
+			message = fmt.Sprintf("Dependency not found: %s (required: %s, release: %s, search pattern: %s)",
+				e.Chart, e.Details.RequiredVersion, e.Details.Release, e.Details.SearchPattern)
+		case e.Details.Namespace != "":
+			message = fmt.Sprintf("Dependency not found: %s (required: %s, namespace: %s)",
+				e.Chart, e.Details.RequiredVersion, e.Details.Namespace)
+		default:
+			message = fmt.Sprintf("Dependency not found: %s (required: %s, search pattern: %s)",
+				e.Chart, e.Details.RequiredVersion, e.Details.SearchPattern)
+		}
+		if e.Details.Hint != "" {
+			message += ": " + e.Details.Hint
+		}
+		return message
 	case ErrorTypeVersionMismatch:
 		return fmt.Sprintf("Version constraint not satisfied: %s (found: %s, required: %s, namespace: %s)",
 			e.Chart, e.Details.FoundVersion, e.Details.RequiredVersion, e.Details.Namespace)
+	case ErrorTypeAppVersionMismatch:
+		return fmt.Sprintf("App version constraint not satisfied: %s (found: %s, required: %s, namespace: %s)",
+			e.Chart, e.Details.FoundAppVersion, e.Details.RequiredAppVersion, e.Details.Namespace)
+	case ErrorTypeInvalidAppVersion:
+		return fmt.Sprintf("App version is not a semantic version: %s (found: %q, required: %s, namespace: %s)",
+			e.Chart, e.Details.FoundAppVersion, e.Details.RequiredAppVersion, e.Details.Namespace)
+	case ErrorTypeNoAlternativeSatisfied:
+		return fmt.Sprintf("No alternative satisfied: %s (%s)",
+			e.Chart, strings.Join(e.Details.Alternatives, "; "))
+	case ErrorTypeConflictDetected:
+		forbidden := e.Details.RequiredVersion
+		if e.Details.RequiredAppVersion != "" {
+			forbidden += ", appVersion: " + e.Details.RequiredAppVersion
+		}
+		message := fmt.Sprintf("Conflicting release deployed: %s (forbidden: %s, releases: %v)",
+			e.Chart, forbidden, e.Details.FoundReleases)
+		if e.Message != "" {
+			message += ": " + e.Message
+		}
+		return message
+	case ErrorTypeContradictoryConstraints:
+		return fmt.Sprintf("Contradictory constraints: %s (%s)",
+			e.Chart, strings.Join(e.Details.DeclaredBy, "; "))
+	case ErrorTypeDependencyCycle:
+		return fmt.Sprintf("Dependency cycle: %s", strings.Join(e.Details.Cycle, " -> "))
+	case ErrorTypeNoTargetNamespace:
+		if position := e.Details.position(); position != "" {
+			return fmt.Sprintf("Target namespace required: %s (%s, %s): %s",
+				e.Chart, e.Details.File, position, e.Message)
+		}
+		return fmt.Sprintf("Target namespace required: %s (%s): %s", e.Chart, e.Details.File, e.Message)
 	case ErrorTypeMultipleDeployments:
 		return fmt.Sprintf("Multiple deployments found: %s in namespaces: %v",
 			e.Chart, e.Details.FoundNamespaces)
@@ -128,17 +418,79 @@ func (e ValidationError) Error() string {
 		return fmt.Sprintf("Multiple instances in namespace: %s in %s (releases: %v)",
 			e.Chart, e.Details.Namespace, e.Details.FoundReleases)
 	case ErrorTypeInvalidDependencyFile:
-		return fmt.Sprintf("Invalid dependencies file: %s (line %d): %s",
-			e.Details.File, e.Details.Line, e.Message)
+		if position := e.Details.position(); position != "" {
+			return fmt.Sprintf("Invalid dependencies file: %s (%s): %s",
+				e.Details.File, position, e.Message)
+		}
+		return fmt.Sprintf("Invalid dependencies file: %s: %s", e.Details.File, e.Message)
 	case ErrorTypeHelmClientError:
 		return fmt.Sprintf("Helm client error: %s", e.Message)
 	case ErrorTypeInvalidVersionConstraint:
+		if position := e.Details.position(); position != "" {
+			return fmt.Sprintf("Invalid version constraint: %s for chart %s (%s, %s)",
+				e.Message, e.Chart, e.Details.File, position)
+		}
+		if e.Details.File != "" {
+			return fmt.Sprintf("Invalid version constraint: %s for chart %s (%s)",
+				e.Message, e.Chart, e.Details.File)
+		}
 		return fmt.Sprintf("Invalid version constraint: %s for chart %s", e.Message, e.Chart)
+	case ErrorTypeNamespaceUnreadable:
+		return fmt.Sprintf("Releases in namespace %s could not be read: %s", e.Details.Namespace, e.Message)
 	default:
 		return fmt.Sprintf("Unknown error: %s", e.Message)
 	}
 }
 
+// position formats the line and, when known, the column of the error. It is empty when
+// the line is unknown.
+func (d ErrorDetails) position() string {
+	if d.Line == 0 {
+		return ""
+	}
+	if d.Column > 0 {
+		return fmt.Sprintf("line %d, column %d", d.Line, d.Column)
+	}
+	return fmt.Sprintf("line %d", d.Line)
+}
+
+// ValidationErrors is a list of validation errors reported together
+type ValidationErrors []ValidationError
+
+// Error joins the messages of all validation errors, one per line
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "\n")
+}
+
+// Append adds the validation errors carried by err to the list. Errors of other types are
+// added as invalid dependency file errors.
+func (e ValidationErrors) Append(err error) ValidationErrors {
+	switch err := err.(type) {
+	case nil:
+		return e
+	case ValidationErrors:
+		return append(e, err...)
+	case ValidationError:
+		return append(e, err)
+	default:
+		return append(e, NewValidationError(ErrorTypeInvalidDependencyFile, "", err.Error(), ErrorDetails{}))
+	}
+}
+
+// Sort orders the validation errors by their position in the file
+func (e ValidationErrors) Sort() {
+	sort.SliceStable(e, func(i, j int) bool {
+		if e[i].Details.Line != e[j].Details.Line {
+			return e[i].Details.Line < e[j].Details.Line
+		}
+		return e[i].Details.Column < e[j].Details.Column
+	})
+}
+
 // NewValidationError creates a new ValidationError with the specified type and details
 func NewValidationError(errorType ErrorType, chart, message string, details ErrorDetails) ValidationError {
 	return ValidationError{
@@ -162,11 +514,48 @@ func IsSystemNamespace(namespace string) bool {
 	return exists && isSystem
 }
 
+// MatchNamespaces returns the namespaces that match the given pattern.
+// An empty pattern matches all non-system namespaces.
+func MatchNamespaces(namespaces []string, pattern string) ([]string, error) {
+	var matchingNamespaces []string
+
+	// Handle empty pattern - return all non-system namespaces
+	if pattern == "" {
+		for _, namespace := range namespaces {
+			if !IsSystemNamespace(namespace) {
+				matchingNamespaces = append(matchingNamespaces, namespace)
+			}
+		}
+		return matchingNamespaces, nil
+	}
+
+	// Compile regex pattern for custom patterns
+	regex, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid namespace pattern '%s': %v", pattern, err)
+	}
+
+	for _, namespace := range namespaces {
+		if regex.MatchString(namespace) {
+			matchingNamespaces = append(matchingNamespaces, namespace)
+		}
+	}
+
+	return matchingNamespaces, nil
+}
+
 // DependencyStatus constants
 const (
-	StatusSatisfied       = "satisfied"
-	StatusNotFound        = "not_found"
-	StatusVersionMismatch = "version_mismatch"
-	StatusMultipleFound   = "multiple_found"
-	StatusError           = "error"
+	StatusSatisfied          = "satisfied"
+	StatusNotFound           = "not_found"
+	StatusVersionMismatch    = "version_mismatch"
+	StatusAppVersionMismatch = "app_version_mismatch"
+	StatusMultipleFound      = "multiple_found"
+	StatusInvalidAppVersion  = "invalid_app_version"
+	StatusNoAlternative      = "no_alternative_satisfied"
+	StatusConflict           = "conflict"
+	StatusNoConflict         = "no_conflict"
+	StatusContradictory      = "contradictory"
+	StatusNoTargetNamespace  = "no_target_namespace"
+	StatusError              = "error"
 )